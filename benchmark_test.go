@@ -0,0 +1,194 @@
+package csvdoc_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	td "github.com/tebruno99/csvdoc/test-data"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+const repeatRows = 1000
+
+// reflectExample has the fields of td.Example without its generated methods, it is read and written with reflection.
+type reflectExample td.Example
+
+// benchDocument repeats the rows of test-data/example.csv repeatRows times below its header. It returns the document
+// and its number of rows.
+func benchDocument(b *testing.B) ([]byte, int) {
+	b.Helper()
+	data, err := os.ReadFile("test-data/example.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	header, rows, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		b.Fatal("document has no rows")
+	}
+	rows = bytes.TrimRight(rows, "\n")
+
+	var doc bytes.Buffer
+	doc.Write(header)
+	doc.WriteByte('\n')
+	for range repeatRows {
+		doc.Write(rows)
+		doc.WriteByte('\n')
+	}
+	return doc.Bytes(), repeatRows * (bytes.Count(rows, []byte("\n")) + 1)
+}
+
+// reportPerRow reports the time per row of a benchmark that handles rows rows per iteration.
+func reportPerRow(b *testing.B, rows int) {
+	b.Helper()
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*rows), "ns/row")
+}
+
+// readAllRows reads every row of r and fails the benchmark on the first error.
+func readAllRows[T any](b *testing.B, r csvdoc.Reader[T]) {
+	b.Helper()
+	for _, err := range csvdoc.All(r) {
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadNoReuseRecord(b *testing.B) {
+	doc, rows := benchDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		cd, err := csvdoc.NewReader[td.Example](bytes.NewReader(doc), csvdoc.WithReuseRecord(false))
+		if err != nil {
+			b.Fatal(err)
+		}
+		readAllRows(b, cd)
+	}
+	reportPerRow(b, rows)
+}
+
+func BenchmarkReflectRead(b *testing.B) {
+	doc, rows := benchDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		cd, err := csvdoc.NewReader[reflectExample](bytes.NewReader(doc))
+		if err != nil {
+			b.Fatal(err)
+		}
+		readAllRows(b, cd)
+	}
+	reportPerRow(b, rows)
+}
+
+func BenchmarkRead(b *testing.B) {
+	doc, rows := benchDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		cd, err := csvdoc.NewReader[td.Example](bytes.NewReader(doc))
+		if err != nil {
+			b.Fatal(err)
+		}
+		readAllRows(b, cd)
+	}
+	reportPerRow(b, rows)
+}
+
+func BenchmarkReadInto(b *testing.B) {
+	doc, rows := benchDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var m td.Example
+	for range b.N {
+		cd, err := csvdoc.NewReader[td.Example](bytes.NewReader(doc))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			err = cd.ReadInto(&m)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	reportPerRow(b, rows)
+}
+
+func BenchmarkParallelRead(b *testing.B) {
+	doc, rows := benchDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		cd, err := csvdoc.NewParallelReader[td.Example](bytes.NewReader(doc))
+		if err != nil {
+			b.Fatal(err)
+		}
+		readAllRows(b, cd)
+	}
+	reportPerRow(b, rows)
+}
+
+func BenchmarkChunkedRead(b *testing.B) {
+	doc, rows := benchDocument(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		cd, err := csvdoc.NewChunkedReader[td.Example](bytes.NewReader(doc), int64(len(doc)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		readAllRows(b, cd)
+	}
+	reportPerRow(b, rows)
+}
+
+func BenchmarkReflectWrite(b *testing.B) {
+	benchmarkWrite[reflectExample](b)
+}
+
+func BenchmarkWrite(b *testing.B) {
+	benchmarkWrite[td.Example](b)
+}
+
+func benchmarkWrite[T td.Example | reflectExample](b *testing.B) {
+	doc, _ := benchDocument(b)
+	cd, err := csvdoc.NewReader[T](bytes.NewReader(doc))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var rows []*T
+	for m, rerr := range cd.All() {
+		if rerr != nil {
+			b.Fatal(rerr)
+		}
+		rows = append(rows, m)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		cw, werr := csvdoc.NewWriter[T](io.Discard)
+		if werr != nil {
+			b.Fatal(werr)
+		}
+		for _, m := range rows {
+			werr = cw.Write(m)
+			if werr != nil {
+				b.Fatal(werr)
+			}
+		}
+		werr = cw.Close()
+		if werr != nil {
+			b.Fatal(werr)
+		}
+	}
+	reportPerRow(b, len(rows))
+}
@@ -0,0 +1,552 @@
+package csvdoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// chunkScanBufferSize is the size of the buffer used to scan a chunk for quotes and new lines.
+const chunkScanBufferSize = 64 << 10
+
+// ChunkedReader is a generic CSV document reader that splits a document into byte ranges and parses the ranges into
+// structs of type T on several goroutines. Rows are returned in document order with the same values and errors as
+// FileReader returns for the same document.
+//
+// Record boundaries are found by the parity of the double quotes before each new line, which requires a well formed
+// document. Lazy quotes and comments are not supported, a bare quote in an unquoted field may split records
+// differently than FileReader.
+type ChunkedReader[T any] struct {
+	readErr         error
+	ra              io.ReaderAt
+	sr              *StreamReader[T]
+	chunks          chan chan chunkResult[T]
+	done            chan struct{}
+	rows            []chunkRow[T]
+	columns         []readColumn
+	wg              sync.WaitGroup
+	size            int64
+	headerEnd       int64
+	headerLines     int
+	fieldsPerRecord int
+	workers         int
+	pos             int
+	line            int
+	started         bool
+	closed          bool
+}
+
+// chunkSpan is the nominal start of a chunk with the number of quotes and new lines in the document before it.
+type chunkSpan struct {
+	start  int64
+	quotes int
+	lines  int
+}
+
+// chunkRow is a record parsed by a chunk goroutine. record is only kept for rows that failed.
+type chunkRow[T any] struct {
+	err    error
+	t      *T
+	record []string
+	line   int
+}
+
+// chunkResult holds the rows of a chunk. err is set when the chunk ended with an error that cannot be skipped. resume
+// is set when the last record of the chunk ended after the start found for the next chunk.
+type chunkResult[T any] struct {
+	err         error
+	rows        []chunkRow[T]
+	resume      int64
+	resumeLines int
+}
+
+// NewChunkedReader creates a new CSV ChunkedReader reading the document of size bytes from r. The header is read and
+// bound the same way as NewReader, parsing starts on the first Read so converters can still be added. The caller
+// remains responsible for closing r unless WithCloseSource(true) is provided.
+func NewChunkedReader[T any](r io.ReaderAt, size int64, opts ...Option[ReaderOption]) (*ChunkedReader[T], error) {
+	plan := planFor[T]()
+	if plan.readErr != nil {
+		return nil, plan.readErr
+	}
+
+	ro := applyReaderOptions(opts)
+	var closer io.Closer
+	if c, ok := r.(io.Closer); ok && ro.closeSource {
+		closer = c
+	}
+
+	return newChunkedReader[T](r, size, closer, plan, ro)
+}
+
+// NewChunkedFileReader creates a new CSV ChunkedReader for the specified file path, see NewChunkedReader. The file is
+// closed by Close or when Read reaches the end of the document.
+func NewChunkedFileReader[T any](fp string, opts ...Option[ReaderOption]) (*ChunkedReader[T], error) {
+	plan := planFor[T]()
+	if plan.readErr != nil {
+		return nil, plan.readErr
+	}
+
+	//nolint:gosec // The purpose of this library is to open user provided files.
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+
+	var cr *ChunkedReader[T]
+	info, err := f.Stat()
+	if err == nil {
+		cr, err = newChunkedReader[T](f, info.Size(), f, plan, applyReaderOptions(opts))
+	}
+	if err != nil {
+		cerr := f.Close()
+		if cerr != nil {
+			log.Println("Error closeing file: ", cerr)
+		}
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// newChunkedReader reads the header line of the document and binds it to the fields of plan using a StreamReader.
+func newChunkedReader[T any](r io.ReaderAt, size int64, closer io.Closer, plan *typePlan, opts *ReaderOption) (*ChunkedReader[T], error) {
+	if opts.lazyQuotes || opts.comment != 0 {
+		return nil, ErrChunkingNotSupported
+	}
+
+	sr, err := newStreamReader[T](io.NewSectionReader(r, 0, size), closer, plan, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &ChunkedReader[T]{sr: sr, ra: r, size: size}
+	if opts.readHeader {
+		cr.headerEnd = sr.cr.InputOffset()
+		_, cr.headerLines, err = cr.count(0, cr.headerEnd)
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.fieldsPerRecord == 0 {
+		// csv.Reader takes the number of fields from the first record, every chunk has to use the same number. Errors
+		// of the first record are returned by the first chunk.
+		_, _ = sr.cr.Read()
+	}
+	cr.fieldsPerRecord = sr.cr.FieldsPerRecord
+
+	return cr, nil
+}
+
+// start launches the goroutines splitting the document after the header and parsing the chunks.
+func (cr *ChunkedReader[T]) start() {
+	cr.workers = cr.sr.opts.workers
+	if cr.workers <= 0 {
+		cr.workers = runtime.GOMAXPROCS(0)
+	}
+
+	cr.started = true
+	cr.columns = append([]readColumn(nil), cr.sr.columns...)
+	cr.run(cr.headerEnd, cr.headerLines)
+}
+
+// run launches the goroutines parsing the document from the record starting at offset from, after lines new lines.
+func (cr *ChunkedReader[T]) run(from int64, lines int) {
+	cr.chunks = make(chan chan chunkResult[T], cr.workers)
+	cr.done = make(chan struct{})
+
+	cr.wg.Add(1)
+	go cr.produce(from, lines)
+}
+
+// stop stops the goroutines launched by run and waits for them.
+func (cr *ChunkedReader[T]) stop() {
+	close(cr.done)
+	cr.wg.Wait()
+}
+
+// produce splits the document into chunks and hands them to worker goroutines. The result channel of every chunk is
+// sent to chunks in document order, which limits the parsed chunks waiting for the caller.
+func (cr *ChunkedReader[T]) produce(from int64, lines int) {
+	defer cr.wg.Done()
+	defer close(cr.chunks)
+
+	spans, err := cr.split(from, lines)
+	if err != nil {
+		out := make(chan chunkResult[T], 1)
+		out <- chunkResult[T]{err: err}
+		select {
+		case cr.chunks <- out:
+		case <-cr.done:
+		}
+		return
+	}
+
+	type chunkJob struct {
+		out   chan chunkResult[T]
+		index int
+	}
+	jobs := make(chan chunkJob)
+	var workerWg sync.WaitGroup
+	workerWg.Add(cr.workers)
+	for range cr.workers {
+		go func() {
+			defer workerWg.Done()
+			for job := range jobs {
+				job.out <- cr.parseChunk(spans, job.index)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		workerWg.Wait()
+	}()
+
+	for i := range len(spans) - 1 {
+		job := chunkJob{index: i, out: make(chan chunkResult[T], 1)}
+		select {
+		case cr.chunks <- job.out:
+		case <-cr.done:
+			return
+		}
+		select {
+		case jobs <- job:
+		case <-cr.done:
+			return
+		}
+	}
+}
+
+// split divides the document from offset from into chunks of the configured size and counts the quotes and new
+// lines before each of them, starting with lines new lines. The returned spans end with the end of the document.
+func (cr *ChunkedReader[T]) split(from int64, lines int) ([]chunkSpan, error) {
+	chunkSize := cr.sr.opts.chunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	n := int((cr.size - from + chunkSize - 1) / chunkSize)
+	spans := make([]chunkSpan, n+1)
+	for i := range spans {
+		spans[i].start = min(from+int64(i)*chunkSize, cr.size)
+	}
+
+	workers := cr.workers
+	counts := make([]chunkSpan, n)
+	var next atomic.Int64
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := range workers {
+		go func() {
+			defer wg.Done()
+			for errs[w] == nil {
+				i := int(next.Add(1) - 1)
+				if i >= n || cr.isClosed() {
+					return
+				}
+				counts[i].quotes, counts[i].lines, errs[w] = cr.count(spans[i].start, spans[i+1].start)
+			}
+		}()
+	}
+	wg.Wait()
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	spans[0].lines = lines
+	for i := range counts {
+		spans[i+1].quotes = spans[i].quotes + counts[i].quotes
+		spans[i+1].lines = spans[i].lines + counts[i].lines
+	}
+	return spans, nil
+}
+
+// isClosed reports if Close was called, without blocking.
+func (cr *ChunkedReader[T]) isClosed() bool {
+	select {
+	case <-cr.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// count returns the number of double quotes and new lines between the offsets from and to.
+func (cr *ChunkedReader[T]) count(from, to int64) (int, int, error) {
+	var quotes, lines int
+	buf := make([]byte, chunkScanBufferSize)
+	sr := io.NewSectionReader(cr.ra, from, to-from)
+	for {
+		n, err := sr.Read(buf)
+		quotes += bytes.Count(buf[:n], []byte{'"'})
+		lines += bytes.Count(buf[:n], []byte{'\n'})
+		if errors.Is(err, io.EOF) {
+			return quotes, lines, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+// recordStart returns the offset of the first record starting at or after the nominal start of chunk i of spans and
+// the number of new lines before it. A new line ends a record when an even number of double quotes precede it.
+func (cr *ChunkedReader[T]) recordStart(spans []chunkSpan, i int) (int64, int, error) {
+	span := spans[i]
+	if i == 0 || span.start == cr.size {
+		return span.start, span.lines, nil
+	}
+
+	offset, quoted, lines := span.start, span.quotes%2 == 1, span.lines
+	buf := make([]byte, chunkScanBufferSize)
+	sr := io.NewSectionReader(cr.ra, span.start, cr.size-span.start)
+	for {
+		n, err := sr.Read(buf)
+		for _, b := range buf[:n] {
+			offset++
+			switch b {
+			case '"':
+				quoted = !quoted
+			case '\n':
+				lines++
+				if !quoted {
+					return offset, lines, nil
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return cr.size, lines, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+}
+
+// parseChunk parses the records of chunk i of spans. The lines of rows and errors are relative to the document.
+func (cr *ChunkedReader[T]) parseChunk(spans []chunkSpan, i int) chunkResult[T] {
+	var res chunkResult[T]
+	if cr.isClosed() {
+		return res
+	}
+	start, lines, err := cr.recordStart(spans, i)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	end, _, err := cr.recordStart(spans, i+1)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	dec := newRowDecoder(cr.columns, cr.sr.generated)
+	csvReader := newCSVReader(io.NewSectionReader(cr.ra, start, cr.size-start), cr.sr.opts)
+	csvReader.FieldsPerRecord = cr.fieldsPerRecord
+	for csvReader.InputOffset() < end-start {
+		record, rerr := csvReader.Read()
+		if errors.Is(rerr, io.EOF) {
+			break
+		}
+		var cerr *csv.ParseError
+		if errors.As(rerr, &cerr) {
+			cerr.StartLine += lines
+			cerr.Line += lines
+		}
+		if rerr != nil && !cr.sr.rowErrors.skippable(rerr) {
+			res.err = rerr
+			return res
+		}
+
+		row := chunkRow[T]{err: rerr}
+		if rerr == nil {
+			row.line, _ = csvReader.FieldPos(0)
+			row.line += lines
+			row.t = new(T)
+			row.err = dec.decode(record, row.t)
+			var perr *ParseError
+			if errors.As(row.err, &perr) {
+				perr.Line, _ = csvReader.FieldPos(min(perr.Index, len(record)-1))
+				perr.Line += lines
+			}
+		}
+		if row.err != nil {
+			row.t = nil
+			row.record = slices.Clone(record)
+		}
+		res.rows = append(res.rows, row)
+	}
+
+	// A malformed quote in a skipped row can end the row after the start found for the next chunk.
+	if offset := start + csvReader.InputOffset(); offset > end {
+		_, n, cerr := cr.count(start, offset)
+		if cerr != nil {
+			res.err = cerr
+			return res
+		}
+		res.resume, res.resumeLines = offset, lines+n
+	}
+	return res
+}
+
+// Read returns the next row. Returns EOF and closes an owned source automatically. Rows that fail to convert are
+// skipped when an ErrorPolicy other than ErrorPolicyFailFast is configured.
+func (cr *ChunkedReader[T]) Read() (*T, error) {
+	return cr.read(context.Background())
+}
+
+// ReadContext is Read that stops once ctx is done, including while waiting for a chunk. The reader is closed and the
+// returned error wraps ctx.Err() with the line the last row read starts on.
+func (cr *ChunkedReader[T]) ReadContext(ctx context.Context) (*T, error) {
+	if ctx.Err() != nil {
+		cr.closeAndLog()
+		return nil, fmt.Errorf("read cancelled after line %d: %w", cr.line, ctx.Err())
+	}
+	return cr.read(ctx)
+}
+
+func (cr *ChunkedReader[T]) read(ctx context.Context) (*T, error) {
+	if cr.closed {
+		if cr.readErr != nil {
+			return nil, cr.readErr
+		}
+		return nil, io.EOF
+	}
+	if !cr.started {
+		cr.start()
+	}
+
+	for {
+		// checked for every row as rows skipped by the ErrorPolicy do not return.
+		if ctx.Err() != nil {
+			cr.closeAndLog()
+			return nil, fmt.Errorf("read cancelled after line %d: %w", cr.line, ctx.Err())
+		}
+		if cr.pos < len(cr.rows) {
+			row := cr.rows[cr.pos]
+			cr.rows[cr.pos] = chunkRow[T]{}
+			cr.pos++
+			if row.err == nil {
+				cr.line = row.line
+				return row.t, nil
+			}
+			err := cr.sr.rowErrors.handle(row.record, row.err)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if cr.readErr != nil {
+			cr.closeAndLog()
+			return nil, cr.readErr
+		}
+
+		res, ok, err := cr.nextChunk(ctx)
+		if err != nil {
+			cr.closeAndLog()
+			return nil, fmt.Errorf("read cancelled after line %d: %w", cr.line, err)
+		}
+		if !ok {
+			cr.closeAndLog()
+			return nil, io.EOF
+		}
+		cr.rows, cr.pos, cr.readErr = res.rows, 0, res.err
+		if res.resume > 0 {
+			// the chunks after this one started inside a record and are parsed again from where this one ended.
+			cr.stop()
+			cr.run(res.resume, res.resumeLines)
+		}
+	}
+}
+
+// nextChunk waits for the rows of the next chunk. It returns false once every chunk was received.
+func (cr *ChunkedReader[T]) nextChunk(ctx context.Context) (chunkResult[T], bool, error) {
+	select {
+	case out, ok := <-cr.chunks:
+		if !ok {
+			return chunkResult[T]{}, false, nil
+		}
+		select {
+		case res := <-out:
+			return res, true, nil
+		case <-ctx.Done():
+			return chunkResult[T]{}, false, ctx.Err()
+		}
+	case <-ctx.Done():
+		return chunkResult[T]{}, false, ctx.Err()
+	}
+}
+
+// closeAndLog closes the reader and logs a failure to close the source.
+func (cr *ChunkedReader[T]) closeAndLog() {
+	cerr := cr.Close()
+	if cerr != nil {
+		log.Println("Error closeing file: ", cerr)
+	}
+}
+
+// Close stops the parsing goroutines and closes the underlying source if it is owned by the reader.
+func (cr *ChunkedReader[T]) Close() error {
+	if !cr.closed {
+		cr.closed = true
+		cr.rows = nil
+		if cr.started {
+			cr.stop()
+		}
+	}
+	return cr.sr.Close()
+}
+
+// Reset is not supported by a ChunkedReader and returns ErrResetNotSupported.
+func (cr *ChunkedReader[T]) Reset() error {
+	return ErrResetNotSupported
+}
+
+// All returns an iterator over the remaining rows for use with range, see All.
+func (cr *ChunkedReader[T]) All() iter.Seq2[*T, error] {
+	return All[T](cr)
+}
+
+// AddConverter adds a customer Conversion func to handle a specific CSV header/struct tag. Converters must be added
+// before the first Read, afterwards ErrReaderStarted is returned.
+func (cr *ChunkedReader[T]) AddConverter(header string, handler Conversion) error {
+	if cr.started {
+		return ErrReaderStarted
+	}
+	return cr.sr.AddConverter(header, handler)
+}
+
+// RemoveConverter removes a customer Conversion func for a specific CSV header/struct tag. Converters must be removed
+// before the first Read, afterwards ErrReaderStarted is returned.
+func (cr *ChunkedReader[T]) RemoveConverter(header string) error {
+	if cr.started {
+		return ErrReaderStarted
+	}
+	return cr.sr.RemoveConverter(header)
+}
+
+// BoundFields returns the names of the struct fields of T that were bound to a csv column, see StreamReader.BoundFields.
+func (cr *ChunkedReader[T]) BoundFields() []string {
+	return cr.sr.BoundFields()
+}
+
+// Errors returns the errors of the rows skipped under ErrorPolicyCollect.
+func (cr *ChunkedReader[T]) Errors() []*ParseError {
+	return cr.sr.Errors()
+}
+
+// SkippedRows returns the number of rows skipped under ErrorPolicySkip or ErrorPolicyCollect.
+func (cr *ChunkedReader[T]) SkippedRows() int {
+	return cr.sr.SkippedRows()
+}
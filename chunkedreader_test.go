@@ -0,0 +1,244 @@
+package csvdoc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+type chunkRow struct {
+	A string `csv:"a"`
+	C string `csv:"c"`
+	B int    `csv:"b"`
+}
+
+// readRows reads the rows of r until io.EOF or the first error, including the errors and skipped rows of the
+// ErrorPolicy.
+type readRows struct {
+	err     error
+	rows    []chunkRow
+	errs    []string
+	skipped int
+}
+
+func readStream(doc string, opts ...csvdoc.Option[csvdoc.ReaderOption]) readRows {
+	sr, err := csvdoc.NewReader[chunkRow](strings.NewReader(doc), opts...)
+	if err != nil {
+		return readRows{err: err}
+	}
+	var res readRows
+	res.rows, res.err = collect[chunkRow](sr)
+	res.skipped = sr.SkippedRows()
+	for _, perr := range sr.Errors() {
+		res.errs = append(res.errs, fmt.Sprintf("%d %v", perr.Line, perr))
+	}
+	return res
+}
+
+func readChunked(doc string, opts ...csvdoc.Option[csvdoc.ReaderOption]) readRows {
+	cr, err := csvdoc.NewChunkedReader[chunkRow](strings.NewReader(doc), int64(len(doc)), opts...)
+	if err != nil {
+		return readRows{err: err}
+	}
+	var res readRows
+	res.rows, res.err = collect[chunkRow](cr)
+	if cerr := cr.Close(); cerr != nil {
+		return readRows{err: cerr}
+	}
+	res.skipped = cr.SkippedRows()
+	for _, perr := range cr.Errors() {
+		res.errs = append(res.errs, fmt.Sprintf("%d %v", perr.Line, perr))
+	}
+	return res
+}
+
+// compareChunked reads doc with a StreamReader and with ChunkedReaders of chunk sizes 1 to 16 bytes and larger, and
+// compares their rows, errors and skipped rows.
+func compareChunked(t *testing.T, doc string, opts ...csvdoc.Option[csvdoc.ReaderOption]) {
+	t.Helper()
+	want := readStream(doc, opts...)
+	sizes := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 33, 1 << 20}
+	for _, size := range sizes {
+		for _, workers := range []int{1, 3} {
+			got := readChunked(doc, append(opts, csvdoc.WithChunkSize(size), csvdoc.WithWorkers(workers))...)
+			if !slices.Equal(got.rows, want.rows) {
+				t.Errorf("chunk size %d workers %d: got rows %v, want %v", size, workers, got.rows, want.rows)
+			}
+			if errorString(got.err) != errorString(want.err) {
+				t.Errorf("chunk size %d workers %d: got error %v, want %v", size, workers, got.err, want.err)
+			}
+			if got.skipped != want.skipped || !slices.Equal(got.errs, want.errs) {
+				t.Errorf("chunk size %d workers %d: got %d skipped %q, want %d skipped %q", size, workers,
+					got.skipped, got.errs, want.skipped, want.errs)
+			}
+		}
+	}
+}
+
+func TestChunkedReaderMatchesReader(t *testing.T) {
+	checkGoroutines(t)
+	skip := csvdoc.WithErrorPolicy(csvdoc.ErrorPolicySkip)
+	collectErrs := csvdoc.WithErrorPolicy(csvdoc.ErrorPolicyCollect)
+	tests := []struct {
+		name string
+		doc  string
+		opts []csvdoc.Option[csvdoc.ReaderOption]
+	}{
+		{name: "plain", doc: "a,b,c\nx,1,y\nz,2,w\n"},
+		{name: "no trailing new line", doc: "a,b,c\nx,1,y\nz,2,w"},
+		{name: "quoted new lines", doc: "a,b,c\n\"x\ny\",1,\"\n\"\n\"p,\"\"q\"\"\n\",2,\"\"\n"},
+		{name: "crlf", doc: "a,b,c\r\nx,1,y\r\n\"p\r\nq\",2,w\r\n"},
+		{
+			name: "bom", doc: "\uFEFFa,b,c\nx,1,y\nz,2,w\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithHeaderNormalization(csvdoc.NormalizeBOM)},
+		},
+		{name: "header only", doc: "a,b,c\n"},
+		{name: "header only without new line", doc: "a,b,c"},
+		{name: "empty", doc: ""},
+		{name: "conversion error", doc: "a,b,c\nx,1,y\nz,bad,w\nq,3,r\n"},
+		{name: "conversion error skipped", doc: "a,b,c\nx,1,y\nz,bad,w\nq,3,r\n", opts: []csvdoc.Option[csvdoc.ReaderOption]{collectErrs}},
+		{name: "bare quote fail fast", doc: "a,b,c\nx,1,y\nz\"q,2,w\nq,3,r\n"},
+		{name: "bare quote skipped", doc: "a,b,c\nx,1,y\nz\"q,2,w\nq,3,r\n", opts: []csvdoc.Option[csvdoc.ReaderOption]{skip}},
+		{
+			name: "bare quotes collected", doc: "a,b,c\nx,1,y\nz\"q,2,w\n\"a\"b,4,\"x\ny\"\nq,3,r\n\"u\nv\",5,e\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{collectErrs},
+		},
+		{name: "wrong field count skipped", doc: "a,b,c\nx,1,y\nz,2\nq,3,r\n", opts: []csvdoc.Option[csvdoc.ReaderOption]{skip}},
+		{name: "unterminated quote", doc: "a,b,c\nx,1,y\n\"z,2,w\nq,3,r\n", opts: []csvdoc.Option[csvdoc.ReaderOption]{skip}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compareChunked(t, tt.doc, tt.opts...)
+		})
+	}
+}
+
+// randomCell returns a cell that may need quoting, bad cells hold a bare quote.
+func randomCell(rng *rand.Rand, bad bool) string {
+	parts := []string{"a", "b", "1", ",", "\n", "\r\n", "\"", " "}
+	var b strings.Builder
+	for range rng.IntN(4) {
+		b.WriteString(parts[rng.IntN(len(parts))])
+	}
+	cell := b.String()
+	if bad {
+		return "x\"" + strings.NewReplacer("\"", "", "\n", "", "\r", "", ",", "").Replace(cell)
+	}
+	if strings.ContainsAny(cell, ",\n\r\"") || rng.IntN(5) == 0 {
+		return "\"" + strings.ReplaceAll(cell, "\"", "\"\"") + "\""
+	}
+	return cell
+}
+
+func TestChunkedReaderRandomDocuments(t *testing.T) {
+	checkGoroutines(t)
+	rng := rand.New(rand.NewPCG(7, 11))
+	for i := range 200 {
+		var b strings.Builder
+		b.WriteString("a,b,c\n")
+		newLine := "\n"
+		if rng.IntN(3) == 0 {
+			newLine = "\r\n"
+		}
+		for range rng.IntN(12) {
+			num := fmt.Sprint(rng.IntN(100))
+			if rng.IntN(10) == 0 {
+				num = "n"
+			}
+			fmt.Fprintf(&b, "%s,%s,%s%s", randomCell(rng, rng.IntN(15) == 0), num, randomCell(rng, false), newLine)
+		}
+		doc := b.String()
+		if rng.IntN(4) == 0 {
+			doc = strings.TrimSuffix(doc, newLine)
+		}
+
+		policy := []csvdoc.ErrorPolicy{csvdoc.ErrorPolicyFailFast, csvdoc.ErrorPolicySkip, csvdoc.ErrorPolicyCollect}[i%3]
+		t.Run(fmt.Sprintf("doc %d", i), func(t *testing.T) {
+			compareChunked(t, doc, csvdoc.WithErrorPolicy(policy))
+			if t.Failed() {
+				t.Logf("document %q", doc)
+			}
+		})
+	}
+}
+
+func TestChunkedReaderCloseAndCancel(t *testing.T) {
+	checkGoroutines(t)
+	doc := numberedDocument(5000, nil)
+	open := func(t *testing.T) *csvdoc.ChunkedReader[numberedRow] {
+		t.Helper()
+		cr, err := csvdoc.NewChunkedReader[numberedRow](strings.NewReader(doc), int64(len(doc)),
+			csvdoc.WithChunkSize(64), csvdoc.WithWorkers(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cr
+	}
+
+	t.Run("close before start", func(t *testing.T) {
+		cr := open(t)
+		err := cr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cr.Read()
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Read after Close: got %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("close after start", func(t *testing.T) {
+		cr := open(t)
+		for range 10 {
+			_, err := cr.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := cr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = cr.Read()
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Read after Close: got %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("break out of All", func(t *testing.T) {
+		for _, err := range open(t).All() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		cr := open(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for range 100 {
+			_, err := cr.ReadContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		cancel()
+		_, err := cr.ReadContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want %v", err, context.Canceled)
+		}
+		_, err = cr.Read()
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Read after cancel: got %v, want %v", err, io.EOF)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+// Package main is the csvdoc-gen command. It reads the Go package in a directory and writes UnmarshalCSVRow and
+// MarshalCSVRow methods for every struct with csv tags, which csvdoc readers and writers use instead of reflection.
+//
+// Usage:
+//
+//	csvdoc-gen [-type Name,...] [-output csvdoc_gen.go] [dir]
+//
+// It is meant to be run by go generate, add `//go:generate go run github.com/tebruno99/csvdoc/cmd/csvdoc-gen` next to
+// the structs and run it again after changing them. Readers and writers return csvdoc.ErrGeneratedCodeOutdated when
+// the generated methods no longer match the struct.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// typedMethods maps field types to the typed RowDecoder and RowEncoder method handling them. Other types use Field.
+//
+//nolint:gochecknoglobals // Lookup table of the csvdoc typed methods.
+var typedMethods = map[string]string{
+	"string":                   "String",
+	"int":                      "Int",
+	"int16":                    "Int16",
+	"int32":                    "Int32",
+	"int64":                    "Int64",
+	"uint":                     "Uint",
+	"uint16":                   "Uint16",
+	"uint32":                   "Uint32",
+	"uint64":                   "Uint64",
+	"float32":                  "Float32",
+	"float64":                  "Float64",
+	"bool":                     "Bool",
+	"time.Time":                "Time",
+	"database/sql.NullString":  "NullString",
+	"database/sql.NullInt64":   "NullInt64",
+	"database/sql.NullInt32":   "NullInt32",
+	"database/sql.NullInt16":   "NullInt16",
+	"database/sql.NullFloat64": "NullFloat64",
+	"database/sql.NullBool":    "NullBool",
+	"database/sql.NullTime":    "NullTime",
+}
+
+// genField is a csv tagged struct field. method is empty for types without a typed method.
+type genField struct {
+	name   string
+	method string
+	index  int
+	read   bool
+	write  bool
+}
+
+// genStruct is a struct type with csv tagged fields.
+type genStruct struct {
+	name   string
+	fields []genField
+}
+
+func main() {
+	types := flag.String("type", "", "comma separated struct names to generate, all structs with csv tags by default")
+	output := flag.String("output", "csvdoc_gen.go", "name of the generated file in the package directory")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var only []string
+	if *types != "" {
+		only = strings.Split(*types, ",")
+	}
+
+	pkg, structs, err := parsePackage(dir, *output, only)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(structs) == 0 {
+		log.Fatalf("no structs with csv tags found in %s", dir)
+	}
+
+	src, err := generate(pkg, structs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, *output), src, 0o600)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parsePackage parses the non test Go files of dir, except the output file, and returns the package name and the
+// structs with csv tags. When only is not empty just the listed structs are returned.
+func parsePackage(dir string, output string, only []string) (string, []genStruct, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var pkg string
+	var structs []genStruct
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		f, perr := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if perr != nil {
+			return "", nil, perr
+		}
+		pkg = f.Name.Name
+
+		imports := fileImports(f)
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts, tok := spec.(*ast.TypeSpec)
+				if !tok || ts.TypeParams != nil || (len(only) > 0 && !slices.Contains(only, ts.Name.Name)) {
+					continue
+				}
+				st, sok := ts.Type.(*ast.StructType)
+				if !sok {
+					continue
+				}
+				fields := structFields(st, imports)
+				if len(fields) > 0 {
+					structs = append(structs, genStruct{name: ts.Name.Name, fields: fields})
+				}
+			}
+		}
+	}
+
+	return pkg, structs, nil
+}
+
+// fileImports maps the names a file uses for its imports to their paths.
+func fileImports(f *ast.File) map[string]string {
+	imports := make(map[string]string, len(f.Imports))
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports
+}
+
+// structFields returns the csv tagged fields of st with their struct field index, following the tag rules of the
+// csvdoc package. Fields skipped on read or write with a "-" name are only generated for the other direction.
+func structFields(st *ast.StructType, imports map[string]string) []genField {
+	var fields []genField
+	index := 0
+	for _, field := range st.Fields.List {
+		names := field.Names
+		if len(names) == 0 {
+			// embedded fields are a single field named after their type.
+			names = []*ast.Ident{nil}
+		}
+		for _, ident := range names {
+			i := index
+			index++
+			if ident == nil || field.Tag == nil {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			read, write, ok := tagNames(reflect.StructTag(tag))
+			if !ok || (!read && !write) {
+				continue
+			}
+			fields = append(fields, genField{
+				name:   ident.Name,
+				method: typedMethods[typeName(field.Type, imports)],
+				index:  i,
+				read:   read,
+				write:  write,
+			})
+		}
+	}
+	return fields
+}
+
+// tagNames reports if a field with tag is read and written, false when it has neither a csv nor a csvidx tag.
+func tagNames(tag reflect.StructTag) (bool, bool, bool) {
+	csvTag, hasCSV := tag.Lookup("csv")
+	_, hasIdx := tag.Lookup("csvidx")
+	if !hasCSV {
+		return hasIdx, hasIdx, hasIdx
+	}
+
+	parts := strings.Split(csvTag, ",")
+	read, _, _ := strings.Cut(parts[0], "|")
+	write := read
+	if len(parts) > 1 {
+		write = parts[1]
+	}
+	return read != "" && read != "-", write != "" && write != "-", true
+}
+
+// typeName returns the type of a field as a type name, qualified by the import path for types of other packages.
+func typeName(expr ast.Expr, imports map[string]string) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			return imports[x.Name] + "." + t.Sel.Name
+		}
+	}
+	return ""
+}
+
+// generate writes the source of the generated file of package pkg.
+func generate(pkg string, structs []genStruct) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by csvdoc-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("import \"github.com/tebruno99/csvdoc\"\n")
+
+	for _, s := range structs {
+		fmt.Fprintf(&b, "\n// UnmarshalCSVRow implements csvdoc.CSVRowUnmarshaler.\n")
+		fmt.Fprintf(&b, "func (m *%s) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {\n", s.name)
+		for _, f := range s.fields {
+			switch {
+			case !f.read:
+			case f.method == "":
+				fmt.Fprintf(&b, "\td.Field(%d)\n", f.index)
+			default:
+				fmt.Fprintf(&b, "\td.%s(%d, &m.%s)\n", f.method, f.index, f.name)
+			}
+		}
+		b.WriteString("\treturn d.Err()\n}\n")
+
+		fmt.Fprintf(&b, "\n// MarshalCSVRow implements csvdoc.CSVRowMarshaler.\n")
+		fmt.Fprintf(&b, "func (m *%s) MarshalCSVRow(e *csvdoc.RowEncoder) error {\n", s.name)
+		for _, f := range s.fields {
+			switch {
+			case !f.write:
+			case f.method == "":
+				fmt.Fprintf(&b, "\te.Field(%d)\n", f.index)
+			default:
+				fmt.Fprintf(&b, "\te.%s(%d, m.%s)\n", f.method, f.index, f.name)
+			}
+		}
+		b.WriteString("\treturn e.Err()\n}\n")
+	}
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const sampleSource = `package main
+
+import (
+	"database/sql"
+	stdtime "time"
+)
+
+// Base is embedded to move the index of the fields after it.
+type Base struct {
+	Note string
+}
+
+type Row struct {
+	Base
+	Skipped   string        ` + "`csv:\"-\"`" + `
+	WriteOnly string        ` + "`csv:\"-,writeOnly\"`" + `
+	ReadOnly  string        ` + "`csv:\"readOnly,\"`" + `
+	Count     sql.NullInt64 ` + "`csv:\"count|total\"`" + `
+	At        stdtime.Time  ` + "`csv:\"at\"`" + `
+	Untagged  int
+	Level     *int          ` + "`csv:\"level\"`" + `
+	ID        int           ` + "`csv:\"id\"`" + `
+}
+
+type Positional struct {
+	Name string ` + "`csvidx:\"0\"`" + `
+	Base
+	Flag  *bool ` + "`csvidx:\"1\"`" + `
+	Count uint  ` + "`csv:\"#2\"`" + `
+}
+
+type Untagged struct {
+	Name string
+}
+`
+
+func writeFile(t *testing.T, fp string, content string) {
+	t.Helper()
+	err := os.WriteFile(fp, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParsePackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "sample.go"), sampleSource)
+
+	pkg, structs, err := parsePackage(dir, "csvdoc_gen.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg != "main" {
+		t.Errorf("got package %q, want main", pkg)
+	}
+	want := []genStruct{
+		{name: "Row", fields: []genField{
+			{name: "WriteOnly", method: "String", index: 2, write: true},
+			{name: "ReadOnly", method: "String", index: 3, read: true},
+			{name: "Count", method: "NullInt64", index: 4, read: true, write: true},
+			{name: "At", method: "Time", index: 5, read: true, write: true},
+			{name: "Level", index: 7, read: true, write: true},
+			{name: "ID", method: "Int", index: 8, read: true, write: true},
+		}},
+		{name: "Positional", fields: []genField{
+			{name: "Name", method: "String", index: 0, read: true, write: true},
+			{name: "Flag", index: 2, read: true, write: true},
+			{name: "Count", method: "Uint", index: 3, read: true, write: true},
+		}},
+	}
+	if !reflect.DeepEqual(structs, want) {
+		t.Errorf("got %+v, want %+v", structs, want)
+	}
+
+	_, structs, err = parsePackage(dir, "csvdoc_gen.go", []string{"Positional"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(structs) != 1 || structs[0].name != "Positional" {
+		t.Errorf("got %+v, want only Positional", structs)
+	}
+}
+
+const roundTripMain = `package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+// reflectRow and reflectPositional have the fields but not the generated methods of Row and Positional.
+type (
+	reflectRow        Row
+	reflectPositional Positional
+)
+
+var (
+	_ csvdoc.CSVRowUnmarshaler = (*Row)(nil)
+	_ csvdoc.CSVRowMarshaler   = (*Positional)(nil)
+)
+
+func readWrite[T any](doc string, readOpts []csvdoc.Option[csvdoc.ReaderOption],
+	writeOpts []csvdoc.Option[csvdoc.WriterOption],
+) ([]T, string) {
+	r, err := csvdoc.NewReader[T](strings.NewReader(doc), readOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var b bytes.Buffer
+	w, err := csvdoc.NewWriter[T](&b, writeOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var rows []T
+	for row, err := range r.All() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		rows = append(rows, *row)
+		err = w.Write(row)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rows, b.String()
+}
+
+func check[G, R any](doc string, readOpts []csvdoc.Option[csvdoc.ReaderOption],
+	writeOpts []csvdoc.Option[csvdoc.WriterOption],
+) {
+	genRows, genOut := readWrite[G](doc, readOpts, writeOpts)
+	rows, out := readWrite[R](doc, readOpts, writeOpts)
+	for i := range rows {
+		if !reflect.DeepEqual(reflect.ValueOf(genRows[i]).Convert(reflect.TypeFor[R]()).Interface(), rows[i]) {
+			log.Fatalf("row %d: generated %+v, reflection %+v", i, genRows[i], rows[i])
+		}
+	}
+	if len(genRows) != len(rows) || genOut != out {
+		log.Fatalf("generated wrote %q, reflection %q", genOut, out)
+	}
+	fmt.Print(genOut)
+}
+
+func main() {
+	check[Row, reflectRow]("id,total,at,readOnly,level\n1,5,2024-01-02 03:04:05,r,7\n2,,2024-01-02,x,\n", nil, nil)
+	check[Positional, reflectPositional]("a,true,3\nb,,4\n",
+		[]csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithReadHeader(false)},
+		[]csvdoc.Option[csvdoc.WriterOption]{csvdoc.WithPositionalColumns[csvdoc.WriterOption](true)})
+}
+`
+
+// TestGeneratedRoundTrip compiles the generated methods of the sample structs and checks that reading and writing
+// with them gives the same rows and output as reflection.
+func TestGeneratedRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "sample.go"), sampleSource)
+	pkg, structs, err := parsePackage(dir, "csvdoc_gen.go", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(pkg, structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "csvdoc_gen.go"), string(src))
+	writeFile(t, filepath.Join(dir, "main.go"), roundTripMain)
+	writeFile(t, filepath.Join(dir, "go.mod"), "module sample\n\ngo 1.24\n\n"+
+		"require github.com/tebruno99/csvdoc v0.0.0\n\nreplace github.com/tebruno99/csvdoc => "+root+"\n")
+
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s\n%s", err, out, src)
+	}
+	want := "writeOnly,count,at,level,id\n,5,2024-01-02 03:04:05,7,1\n,,2024-01-02 00:00:00,,2\n" +
+		"a,true,3\nb,,4\n"
+	if string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
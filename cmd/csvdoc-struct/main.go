@@ -0,0 +1,301 @@
+// Package main is the csvdoc-struct command. It reads the header and sample rows of a csv file and writes a Go struct
+// with csv tags for it, the field types are inferred from the sample values.
+//
+// Usage:
+//
+//	csvdoc-struct [-type Name] [-package main] [-rows 1000] [-conversions] [-output file.go] file.csv
+//
+// Columns are typed int64, uint, float64, bool or time.Time when every sampled value can be read by the default
+// converters, the sql.Null variant is used when blank values occur and string otherwise. With -conversions columns of
+// dates in layouts the default converters do not read, like "1/2012", get a time type and a Conversion for AddConverter.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+// initialisms are name parts written in upper case in Go field names.
+//
+//nolint:gochecknoglobals // Read only lookup table.
+var initialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "UUID": true, "IP": true, "API": true, "HTTP": true, "JSON": true, "XML": true,
+	"SQL": true, "UTC": true, "SKU": true,
+}
+
+// field is a struct field written for a column. column is the name converters of the field are added under, the
+// header or "#N" for columns bound by position. layouts are set for columns read by a generated Conversion.
+type field struct {
+	name    string
+	typ     string
+	tag     string
+	header  string
+	column  string
+	sample  string
+	layouts []string
+}
+
+func main() {
+	typeName := flag.String("type", "", "name of the struct, derived from the file name by default")
+	pkg := flag.String("package", "main", "package name of the generated file")
+	rows := flag.Int("rows", 1000, "number of rows to sample, 0 reads the whole file")
+	comma := flag.String("comma", ",", "field delimiter of the csv file")
+	conversions := flag.Bool("conversions", false, "write Conversion funcs for date columns the default converters cannot read")
+	header := flag.Bool("header", true, "the first row of the file is a header, otherwise fields are bound by position")
+	output := flag.String("output", "", "file to write, standard output by default")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		log.Fatal("usage: csvdoc-struct [flags] file.csv")
+	}
+	fp := flag.Arg(0)
+	if *typeName == "" {
+		*typeName = goName(strings.TrimSuffix(filepath.Base(fp), filepath.Ext(fp)))
+	}
+	delim := []rune(*comma)
+	if len(delim) != 1 {
+		log.Fatal("comma must be a single character")
+	}
+
+	schema, err := inferSchema(fp, delim[0], *header, *rows)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(*pkg, *typeName, filepath.Base(fp), schema.Columns, *header, *conversions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0o600)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// inferSchema infers the schema of the first rows rows of the csv file at fp.
+func inferSchema(fp string, comma rune, header bool, rows int) (*csvdoc.Schema, error) {
+	//nolint:gosec // The purpose of this tool is to open user provided files.
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cerr := f.Close()
+		if cerr != nil {
+			log.Println("Error closeing file: ", cerr)
+		}
+	}()
+
+	return csvdoc.InferSchema(f, csvdoc.WithComma(comma), csvdoc.WithReadHeader(header), csvdoc.WithSampleRows(rows),
+		csvdoc.WithFieldsPerRecord(-1))
+}
+
+// goName turns a csv header into an exported Go identifier, splitting it into words at separators and case changes.
+func goName(header string) string {
+	var words []string
+	var word []rune
+	runes := []rune(header)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var b strings.Builder
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "Column" + name
+	}
+	return name
+}
+
+// tagSafe reports whether header can be written as the name of a csv tag. Names holding the separators of the tag
+// grammar, quotes, or taken by it like "-" and "#N" cannot.
+func tagSafe(header string) bool {
+	return header != "" && header != "-" && !strings.HasPrefix(header, "#") && !strings.ContainsAny(header, ",|\"`")
+}
+
+// fields returns the struct fields of columns with unique names. With conversions columns of dates the default
+// converters cannot read get a time type and the layouts of their Conversion. Columns without a header, with a header
+// that is not tagSafe or with a header found more than once are bound by position.
+func fields(columns []csvdoc.ColumnSchema, header bool, conversions bool) []field {
+	headers := make(map[string]int, len(columns))
+	for _, c := range columns {
+		headers[c.Name]++
+	}
+	used := make(map[string]bool, len(columns))
+	out := make([]field, 0, len(columns))
+	for _, c := range columns {
+		f := field{header: c.Name, column: c.Name, typ: c.Type.String()}
+		if conversions && c.Type.Kind() == reflect.String && len(c.Layouts) > 0 {
+			f.typ = "time.Time"
+			if c.Nulls > 0 {
+				f.typ = "sql.NullTime"
+			}
+			f.layouts = c.Layouts
+			f.sample = c.Samples[0]
+		}
+		switch {
+		case header && tagSafe(c.Name) && headers[c.Name] == 1:
+			f.name = goName(c.Name)
+			f.tag = "csv:" + strconv.Quote(c.Name)
+		case header:
+			f.name = goName(c.Name)
+			f.column = "#" + strconv.Itoa(c.Index)
+			f.tag = "csvidx:" + strconv.Quote(strconv.Itoa(c.Index))
+		default:
+			f.name = fmt.Sprintf("Column%d", c.Index)
+			f.tag = "csvidx:" + strconv.Quote(strconv.Itoa(c.Index))
+		}
+		if used[f.name] {
+			n := 2
+			for used[fmt.Sprintf("%s%d", f.name, n)] {
+				n++
+			}
+			f.name = fmt.Sprintf("%s%d", f.name, n)
+		}
+		used[f.name] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// generate writes the source of the struct typeName for the columns of the csv file named file.
+func generate(pkg string, typeName string, file string, columns []csvdoc.ColumnSchema, header bool,
+	conversions bool,
+) ([]byte, error) {
+	bom := header && len(columns) > 0 && strings.HasPrefix(columns[0].Name, "\uFEFF")
+	if bom {
+		columns[0].Name = strings.TrimPrefix(columns[0].Name, "\uFEFF")
+	}
+	fs := fields(columns, header, conversions)
+
+	var usesSQL, usesTime, converts bool
+	for _, f := range fs {
+		usesSQL = usesSQL || strings.HasPrefix(f.typ, "sql.")
+		usesTime = usesTime || f.typ == "time.Time" || f.layouts != nil
+		converts = converts || f.layouts != nil
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by csvdoc-struct from %s, edit it as needed.\n\n", file)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	var imports []string
+	if usesSQL {
+		imports = append(imports, "\"database/sql\"")
+	}
+	if converts {
+		imports = append(imports, "\"errors\"", "\"reflect\"")
+	}
+	if usesTime {
+		imports = append(imports, "\"time\"")
+	}
+	if converts {
+		imports = append(imports, "", "\"github.com/tebruno99/csvdoc\"")
+	}
+	if len(imports) > 0 {
+		fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+
+	fmt.Fprintf(&b, "// %s is a struct that defines each column of %s.\n", typeName, file)
+	if bom {
+		fmt.Fprintf(&b, "// %s starts with a byte order mark, read it with WithHeaderNormalization(csvdoc.NormalizeBOM).\n", file)
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", typeName)
+	for _, f := range fs {
+		if f.column != f.header {
+			fmt.Fprintf(&b, "// %s is the column %q, bound by position as its header cannot be a unique csv tag.\n",
+				f.name, f.header)
+		}
+		fmt.Fprintf(&b, "%s %s `%s`\n", f.name, f.typ, f.tag)
+	}
+	b.WriteString("}\n")
+
+	if converts {
+		writeConversions(&b, typeName, fs)
+	}
+
+	return format.Source(b.Bytes())
+}
+
+// writeConversions writes a func returning the Conversion of each column read with layouts, followed by the
+// Conversion funcs.
+func writeConversions(b *bytes.Buffer, typeName string, fs []field) {
+	fmt.Fprintf(b, "\n// %sConversions returns the Conversion funcs for the columns of %s the default converters cannot\n",
+		typeName, typeName)
+	b.WriteString("// read, add each of them with AddConverter or WithReadConverter.\n")
+	fmt.Fprintf(b, "func %sConversions() map[string]csvdoc.Conversion {\n", typeName)
+	b.WriteString("return map[string]csvdoc.Conversion{\n")
+	for _, f := range fs {
+		if f.layouts != nil {
+			fmt.Fprintf(b, "%s: convert%s%s,\n", strconv.Quote(f.column), typeName, f.name)
+		}
+	}
+	b.WriteString("}\n}\n")
+
+	for _, f := range fs {
+		if f.layouts == nil {
+			continue
+		}
+		quoted := make([]string, 0, len(f.layouts))
+		for _, layout := range f.layouts {
+			quoted = append(quoted, strconv.Quote(layout))
+		}
+
+		fmt.Fprintf(b, "\n// convert%s%s converts %s cells written like %q.\n", typeName, f.name, f.header, f.sample)
+		fmt.Fprintf(b, "func convert%s%s(s string, field *reflect.Value) error {\n", typeName, f.name)
+		value := "val"
+		if f.typ == "sql.NullTime" {
+			b.WriteString("if s == \"\" {\nreturn nil\n}\n")
+			value = "sql.NullTime{Time: val, Valid: true}"
+		}
+		fmt.Fprintf(b, "formats := []string{%s}\n", strings.Join(quoted, ", "))
+		b.WriteString("for _, format := range formats {\n")
+		b.WriteString("val, err := time.Parse(format, s)\n")
+		fmt.Fprintf(b, "if err == nil {\nfield.Set(reflect.ValueOf(%s))\nreturn nil\n}\n}\n", value)
+		b.WriteString("return errors.New(\"cannot convert string to time\")\n}\n")
+	}
+}
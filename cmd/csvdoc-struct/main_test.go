@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+func columns(names ...string) []csvdoc.ColumnSchema {
+	cols := make([]csvdoc.ColumnSchema, 0, len(names))
+	for i, name := range names {
+		cols = append(cols, csvdoc.ColumnSchema{Name: name, Index: i, Type: reflect.TypeFor[string]()})
+	}
+	return cols
+}
+
+func TestFieldNamesUnique(t *testing.T) {
+	fs := fields(columns("id", "id_2", "id", "Id", "id_3"), true, false)
+	var names, tags []string
+	for _, f := range fs {
+		names = append(names, f.name)
+		tags = append(tags, f.tag)
+	}
+	wantNames := []string{"ID", "ID2", "ID3", "ID4", "ID32"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("got names %q, want %q", names, wantNames)
+	}
+	wantTags := []string{`csvidx:"0"`, `csv:"id_2"`, `csvidx:"2"`, `csv:"Id"`, `csv:"id_3"`}
+	if !reflect.DeepEqual(tags, wantTags) {
+		t.Errorf("got tags %q, want %q", tags, wantTags)
+	}
+}
+
+func TestFieldTags(t *testing.T) {
+	tests := []struct {
+		header string
+		tag    string
+		column string
+	}{
+		{header: "name", tag: `csv:"name"`, column: "name"},
+		{header: "first, last", tag: `csvidx:"0"`, column: "#0"},
+		{header: "a|b", tag: `csvidx:"0"`, column: "#0"},
+		{header: `say "hi"`, tag: `csvidx:"0"`, column: "#0"},
+		{header: "tick`", tag: `csvidx:"0"`, column: "#0"},
+		{header: "-", tag: `csvidx:"0"`, column: "#0"},
+		{header: "#1", tag: `csvidx:"0"`, column: "#0"},
+		{header: "", tag: `csvidx:"0"`, column: "#0"},
+	}
+	for _, tt := range tests {
+		f := fields(columns(tt.header), true, false)[0]
+		if f.tag != tt.tag || f.column != tt.column {
+			t.Errorf("header %q: got tag %s column %q, want %s column %q", tt.header, f.tag, f.column, tt.tag, tt.column)
+		}
+	}
+}
+
+func TestGenerateBindsUnsafeHeadersByPosition(t *testing.T) {
+	src, err := generate("main", "Doc", "doc.csv", columns("name", "a,b", "Name"), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(strings.Fields(string(src)), " ")
+	wants := []string{"Name string `csv:\"name\"`", "AB string `csvidx:\"1\"`", "Name2 string `csv:\"Name\"`"}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated source is missing %s:\n%s", want, src)
+		}
+	}
+}
+
+// TestGenerateDuplicateHeaders compiles the struct written for a document with duplicated headers and reads the
+// document with it.
+func TestGenerateDuplicateHeaders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	fp := filepath.Join(dir, "doc.csv")
+	writeFile(t, fp, "id,name,id\n1,a,2\n3,b,4\n")
+	schema, err := inferSchema(fp, ',', true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("main", "Doc", "doc.csv", schema.Columns, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "doc.go"), string(src))
+	writeFile(t, filepath.Join(dir, "go.mod"), "module doctest\n\ngo 1.24\n\n"+
+		"require github.com/tebruno99/csvdoc v0.0.0\n\nreplace github.com/tebruno99/csvdoc => "+root+"\n")
+	writeFile(t, filepath.Join(dir, "main.go"), `package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+func main() {
+	r, err := csvdoc.NewFileReader[Doc]("doc.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for row, err := range r.All() {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%+v\n", *row)
+	}
+}
+`)
+
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s\n%s", err, out, src)
+	}
+	want := "{ID:1 Name:a ID2:2}\n{ID:3 Name:b ID2:4}\n"
+	if string(out) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+func writeFile(t *testing.T, fp string, content string) {
+	t.Helper()
+	err := os.WriteFile(fp, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,12 +2,8 @@
 package main
 
 import (
-	"database/sql"
-	"errors"
 	"fmt"
-	"io"
 	"log"
-	"reflect"
 	"time"
 
 	td "github.com/tebruno99/csvdoc/test-data"
@@ -24,31 +20,9 @@ func main() {
 		log.Fatal(err)
 	}
 
-	err = cd.AddConverter("MonYear", csvdoc.Conversion(func(s string, field *reflect.Value) error {
-		formats := []string{"1/2006"}
-		if s != "" {
-			for _, format := range formats {
-				val, serr := time.Parse(format, s)
-				if serr == nil {
-					field.Set(reflect.ValueOf(sql.NullTime{Time: val, Valid: true}))
-					return nil
-				}
-			}
-			return errors.New("cannot convert string to time")
-		}
-		return nil
-	}))
-	if err != nil {
-		log.Fatalf("Failed to install converter for MonYear: %s", err)
-	}
-
 	examples := make([]*td.Example, 0)
-	for {
-		m, rerr := cd.Read()
+	for m, rerr := range cd.All() {
 		if rerr != nil {
-			if errors.Is(rerr, io.EOF) {
-				break
-			}
 			log.Fatalf("Error reading CSV file: %v", rerr)
 		}
 		examples = append(examples, m)
@@ -0,0 +1,363 @@
+package csvdoc_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+// roundTrip reads doc into rows of T and of G, a copy of T with the methods csvdoc-gen writes for it, and checks both
+// against want. The rows are written back with writeOpts, expecting written.
+func roundTrip[T, G any](t *testing.T, doc string, want []T, written string,
+	readOpts []csvdoc.Option[csvdoc.ReaderOption], writeOpts []csvdoc.Option[csvdoc.WriterOption],
+) {
+	t.Helper()
+	rows, err := readAll[T](t, doc, readOpts...)
+	if err != nil {
+		t.Fatalf("reflection read: %v", err)
+	}
+	genRows, err := readAll[G](t, doc, readOpts...)
+	if err != nil {
+		t.Fatalf("generated read: %v", err)
+	}
+
+	got := make([]T, 0, len(rows))
+	for _, row := range rows {
+		got = append(got, *row)
+	}
+	genGot := make([]T, 0, len(genRows))
+	for _, row := range genRows {
+		genGot = append(genGot, reflect.ValueOf(*row).Convert(reflect.TypeFor[T]()).Interface().(T))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reflection read: got %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(genGot, want) {
+		t.Errorf("generated read: got %+v, want %+v", genGot, want)
+	}
+
+	out, err := writeRows(t, rows, writeOpts...)
+	if err != nil {
+		t.Fatalf("reflection write: %v", err)
+	}
+	if string(out) != written {
+		t.Errorf("reflection write: got %q, want %q", out, written)
+	}
+	out, err = writeRows(t, genRows, writeOpts...)
+	if err != nil {
+		t.Fatalf("generated write: %v", err)
+	}
+	if string(out) != written {
+		t.Errorf("generated write: got %q, want %q", out, written)
+	}
+}
+
+// level reads and writes the names of its values with encoding.TextUnmarshaler and encoding.TextMarshaler.
+type level int
+
+func (l level) MarshalText() ([]byte, error) {
+	switch l {
+	case 1:
+		return []byte("low"), nil
+	case 2:
+		return []byte("high"), nil
+	}
+	return nil, fmt.Errorf("unknown level %d", int(l))
+}
+
+func (l *level) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", b)
+	}
+	return nil
+}
+
+type textRow struct {
+	Name  string `csv:"name"`
+	Level level  `csv:"level"`
+}
+
+type generatedTextRow textRow
+
+func (m *generatedTextRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.String(0, &m.Name)
+	d.Field(1)
+	return d.Err()
+}
+
+func (m *generatedTextRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.String(0, m.Name)
+	e.Field(1)
+	return e.Err()
+}
+
+func TestTextMarshaler(t *testing.T) {
+	doc := "name,level\na,low\nb,high\n"
+	want := []textRow{{Name: "a", Level: 1}, {Name: "b", Level: 2}}
+	roundTrip[textRow, generatedTextRow](t, doc, want, doc, nil, nil)
+
+	_, err := readAll[textRow](t, "name,level\na,medium\n")
+	if err == nil || err.Error() != `line 2, column 1 "level" (field Level csvdoc_test.level): cannot convert "medium": `+
+		`unknown level "medium"` {
+		t.Errorf("got %v, want the error of UnmarshalText", err)
+	}
+}
+
+// point is written with fmt.Stringer, it has no other converter.
+type point struct {
+	X, Y int
+}
+
+func (p point) String() string {
+	return fmt.Sprintf("%d:%d", p.X, p.Y)
+}
+
+type stringerRow struct {
+	P   *point `csv:"p"`
+	Pos point  `csv:"pos"`
+}
+
+type generatedStringerRow stringerRow
+
+func (m *generatedStringerRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Field(0)
+	d.Field(1)
+	return d.Err()
+}
+
+func (m *generatedStringerRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Field(0)
+	e.Field(1)
+	return e.Err()
+}
+
+func TestWriteStringer(t *testing.T) {
+	rows := []*stringerRow{{P: &point{X: 1, Y: 2}, Pos: point{X: 3, Y: 4}}, {Pos: point{X: 5}}}
+	want := "p,pos\n1:2,3:4\n,5:0\n"
+	got, err := writeRows(t, rows, csvdoc.WithStringer[csvdoc.WriterOption](true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	genRows := []*generatedStringerRow{(*generatedStringerRow)(rows[0]), (*generatedStringerRow)(rows[1])}
+	got, err = writeRows(t, genRows, csvdoc.WithStringer[csvdoc.WriterOption](true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("generated: got %q, want %q", got, want)
+	}
+
+	_, err = writeRows(t, rows)
+	if !errors.Is(err, csvdoc.ErrConverterNotFoundForType) {
+		t.Errorf("without WithStringer: got %v, want %v", err, csvdoc.ErrConverterNotFoundForType)
+	}
+}
+
+// code is read with sql.Scanner and written with driver.Valuer. Null records that a blank cell was scanned as nil.
+type code struct {
+	V    string
+	Null bool
+}
+
+func (c *code) Scan(src any) error {
+	switch x := src.(type) {
+	case nil:
+		*c = code{Null: true}
+	case string:
+		*c = code{V: x}
+	default:
+		return fmt.Errorf("cannot scan %T", src)
+	}
+	return nil
+}
+
+func (c code) Value() (driver.Value, error) {
+	if c.Null {
+		return nil, nil
+	}
+	return c.V, nil
+}
+
+// cents is an amount written by its driver.Valuer as int64.
+type cents int64
+
+func (c *cents) Scan(src any) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T", src)
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(s, ".", ""), 10, 64)
+	*c = cents(n)
+	return err
+}
+
+func (c cents) Value() (driver.Value, error) {
+	return int64(c), nil
+}
+
+type scannerRow struct {
+	Code   code  `csv:"code"`
+	Amount cents `csv:"amount"`
+}
+
+type generatedScannerRow scannerRow
+
+func (m *generatedScannerRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Field(0)
+	d.Field(1)
+	return d.Err()
+}
+
+func (m *generatedScannerRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Field(0)
+	e.Field(1)
+	return e.Err()
+}
+
+func TestScannerValuer(t *testing.T) {
+	doc := "code,amount\nab,1.25\n,3.00\n"
+	want := []scannerRow{{Code: code{V: "ab"}, Amount: 125}, {Code: code{Null: true}, Amount: 300}}
+	roundTrip[scannerRow, generatedScannerRow](t, doc, want, "code,amount\nab,125\n,300\n", nil, nil)
+}
+
+type genericNullRow struct {
+	When  sql.Null[time.Time] `csv:"when"`
+	Name  sql.Null[string]    `csv:"name"`
+	Count sql.Null[uint]      `csv:"count"`
+	Level sql.Null[level]     `csv:"level"`
+}
+
+type generatedGenericNullRow genericNullRow
+
+func (m *generatedGenericNullRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Field(0)
+	d.Field(1)
+	d.Field(2)
+	d.Field(3)
+	return d.Err()
+}
+
+func (m *generatedGenericNullRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Field(0)
+	e.Field(1)
+	e.Field(2)
+	e.Field(3)
+	return e.Err()
+}
+
+func TestGenericNull(t *testing.T) {
+	doc := "when,name,count,level\n2024-01-02 03:04:05,x,7,low\n,,,\n"
+	want := []genericNullRow{
+		{
+			When:  sql.Null[time.Time]{V: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true},
+			Name:  sql.Null[string]{V: "x", Valid: true},
+			Count: sql.Null[uint]{V: 7, Valid: true},
+			Level: sql.Null[level]{V: 1, Valid: true},
+		},
+		{},
+	}
+	roundTrip[genericNullRow, generatedGenericNullRow](t, doc, want, doc, nil, nil)
+
+	_, err := readAll[genericNullRow](t, "when,name,count,level\n,,-1,\n")
+	var perr *csvdoc.ParseError
+	if !errors.As(err, &perr) || perr.Header != "count" {
+		t.Errorf("got %v, want a ParseError for the negative count", err)
+	}
+}
+
+type pointerRow struct {
+	When  *time.Time `csv:"when"`
+	Name  *string    `csv:"name"`
+	Count *int       `csv:"count"`
+	Level *level     `csv:"level"`
+	OK    *bool      `csv:"ok"`
+}
+
+type generatedPointerRow pointerRow
+
+func (m *generatedPointerRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Field(0)
+	d.Field(1)
+	d.Field(2)
+	d.Field(3)
+	d.Field(4)
+	return d.Err()
+}
+
+func (m *generatedPointerRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Field(0)
+	e.Field(1)
+	e.Field(2)
+	e.Field(3)
+	e.Field(4)
+	return e.Err()
+}
+
+func TestPointerFields(t *testing.T) {
+	doc := "when,name,count,level,ok\n2024-01-02 03:04:05,x,-7,high,true\n,,,,\n"
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	name, count, lvl, ok := "x", -7, level(2), true
+	want := []pointerRow{{When: &when, Name: &name, Count: &count, Level: &lvl, OK: &ok}, {}}
+	roundTrip[pointerRow, generatedPointerRow](t, doc, want, doc, nil, nil)
+}
+
+type nullTokenRow struct {
+	Count *int           `csv:"count"`
+	Name  sql.NullString `csv:"name"`
+	Note  sql.NullString `csv:"note" csvnull:"N/A|-"`
+	Plain string         `csv:"plain"`
+	Code  code           `csv:"code"`
+}
+
+type generatedNullTokenRow nullTokenRow
+
+func (m *generatedNullTokenRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Field(0)
+	d.NullString(1, &m.Name)
+	d.NullString(2, &m.Note)
+	d.String(3, &m.Plain)
+	d.Field(4)
+	return d.Err()
+}
+
+func (m *generatedNullTokenRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Field(0)
+	e.NullString(1, m.Name)
+	e.NullString(2, m.Note)
+	e.String(3, m.Plain)
+	e.Field(4)
+	return e.Err()
+}
+
+func TestNullTokens(t *testing.T) {
+	doc := "count,name,note,plain,code\n\\N,NULL,N/A,NULL,NULL\n1,x,NULL,y,c\n2,,-,,\n"
+	count1, count2 := 1, 2
+	want := []nullTokenRow{
+		{Plain: "NULL", Code: code{Null: true}},
+		{
+			Count: &count1, Name: sql.NullString{String: "x", Valid: true},
+			Note: sql.NullString{String: "NULL", Valid: true}, Plain: "y", Code: code{V: "c"},
+		},
+		{Count: &count2, Code: code{Null: true}},
+	}
+	written := "count,name,note,plain,code\n\\N,\\N,N/A,NULL,\\N\n1,x,NULL,y,c\n2,\\N,N/A,,\\N\n"
+	roundTrip[nullTokenRow, generatedNullTokenRow](t, doc, want, written,
+		[]csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithNullTokens("NULL", "\\N")},
+		[]csvdoc.Option[csvdoc.WriterOption]{csvdoc.WithNullToken[csvdoc.WriterOption]("\\N")})
+}
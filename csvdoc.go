@@ -3,37 +3,204 @@ package csvdoc
 
 import (
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 )
 
-// buildReflectTagIndexCache builds a map[string]int of the field tag name and field index so this does not have to
-// be completed on every Read().
-func buildReflectTagIndexCache[T any](forWrite bool) (map[string]int, error) {
-	// Precalculate the struct field indexes
-	rt := new(T)
-	ft := reflect.TypeOf(rt).Elem()
+// structField is a csv tagged struct field. name is the read or write column name depending on how the cache was built.
+// conv and toString are the default converters of the field type, nil when the type has none. stringer formats fields
+// implementing fmt.Stringer for writers enabling WithStringer. nullTokens are the tokens of a csvnull tag, nil without
+// one, and nullable is set for field types that can hold a null read from a csv cell. time is the format of time
+// fields with time tags, nil for the default layouts.
+type structField struct {
+	typ        reflect.Type
+	time       *timeFormat
+	conv       Conversion
+	toString   ToStringConversion
+	stringer   ToStringConversion
+	name       string
+	fieldName  string
+	nullTokens []string
+	aliases    []string
+	index      int
+	order      int
+	position   int
+	nullable   bool
+	optional   bool
+}
+
+// csvTagParts is a parsed csv struct tag.
+type csvTagParts struct {
+	read    string
+	aliases []string
+	write   string
+	flags   []string
+}
+
+// parseCSVTag splits a csv struct tag of the form "read[|alias...][,write[,flag...]]" into its parts. Without a write
+// part the write name is the read name, an empty write part like "-" keeps the field out of the written columns.
+func parseCSVTag(tag string) csvTagParts {
+	parts := strings.Split(tag, ",")
+	names := strings.Split(parts[0], "|")
+	p := csvTagParts{read: names[0], aliases: names[1:], write: names[0]}
+	if len(parts) > 1 {
+		p.write = parts[1]
+	}
+	if len(parts) > 2 {
+		p.flags = parts[2:]
+	}
+	return p
+}
 
-	fieldIndexes := make(map[string]int, ft.NumField())
+// buildReflectFieldCache builds the list of csv tagged fields of struct type ft in struct declaration order. An optional csvorder
+// tag holding a non-negative number is parsed into order. A csvnull tag lists the "|" separated null tokens of the
+// column, csvtime, csvtimewrite and csvtz tags the time format. The zero based column position used by headerless documents
+// comes from a csvidx tag or a csv tag name of the form "#N". Fields with only a csvidx tag are named "#N". Fields
+// without an order or position have -1. The "optional" csv tag flag marks columns that may be missing on read. Read
+// names may list aliases separated by "|", name holds the first one.
+func buildReflectFieldCache(ft reflect.Type, forWrite bool) ([]structField, error) {
+	fields := make([]structField, 0, ft.NumField())
+	seen := make(map[string]struct{}, ft.NumField())
 
 	for i := range ft.NumField() {
 		csvTag := ft.Field(i).Tag
-		if _, ok := csvTag.Lookup("csv"); !ok {
+		tag, hasCSV := csvTag.Lookup("csv")
+		idxTag, hasIdx := csvTag.Lookup("csvidx")
+		if !hasCSV && !hasIdx {
 			continue
 		}
-		tag := csvTag.Get("csv")
-		readTag := strings.Split(tag, ",")
-		if forWrite {
-			if len(readTag) > 1 {
-				readTag = readTag[1:]
+
+		position := -1
+		if hasIdx {
+			n, err := strconv.Atoi(idxTag)
+			if err != nil || n < 0 {
+				return nil, ErrInvalidStructTag
 			}
+			position = n
+			if !hasCSV {
+				tag = "#" + idxTag
+			}
+		}
+
+		parts := parseCSVTag(tag)
+		name, aliases := parts.read, parts.aliases
+		if forWrite {
+			name, aliases = parts.write, nil
 		}
-		if _, tok := fieldIndexes[readTag[0]]; tok {
+		if _, tok := seen[name]; tok {
 			return nil, ErrStructTagDuplicate
 		}
-		if readTag[0] != "" && readTag[0] != "-" {
-			fieldIndexes[readTag[0]] = i
+		if name == "" || name == "-" {
+			continue
+		}
+		seen[name] = struct{}{}
+		for _, alias := range aliases {
+			if alias == "" {
+				return nil, ErrInvalidStructTag
+			}
+			if _, tok := seen[alias]; tok {
+				return nil, ErrStructTagDuplicate
+			}
+			seen[alias] = struct{}{}
+		}
+
+		if position < 0 && strings.HasPrefix(name, "#") {
+			n, err := strconv.Atoi(name[1:])
+			if err == nil && n >= 0 {
+				position = n
+			}
+		}
+
+		// flags other than optional are ignored.
+		optional := slices.Contains(parts.flags, "optional")
+
+		order := -1
+		if orderTag, ok := csvTag.Lookup("csvorder"); ok {
+			n, err := strconv.Atoi(orderTag)
+			if err != nil || n < 0 {
+				return nil, ErrInvalidStructTag
+			}
+			order = n
+		}
+		var nullTokens []string
+		if nullTag, ok := csvTag.Lookup("csvnull"); ok {
+			nullTokens = strings.Split(nullTag, "|")
+		}
+		tf, err := parseTimeTags(csvTag)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, structField{
+			time:       tf,
+			nullTokens: nullTokens,
+			typ:        ft.Field(i).Type,
+			name:       name,
+			aliases:    aliases,
+			fieldName:  ft.Field(i).Name,
+			index:      i,
+			order:      order,
+			position:   position,
+			optional:   optional,
+		})
+	}
+
+	return fields, nil
+}
+
+// sortFieldsByOrder places each field with a csvorder tag at the zero based output column of its order, the remaining
+// fields fill the free columns in declaration order. Two fields sharing an order return ErrDuplicateColumnOrder, an
+// order past the last column, which would leave a gap, returns ErrColumnOrderOutOfRange.
+func sortFieldsByOrder(fields []structField) ([]structField, error) {
+	sorted := make([]structField, len(fields))
+	placed := make([]bool, len(fields))
+	for _, f := range fields {
+		if f.order < 0 {
+			continue
+		}
+		if f.order >= len(fields) {
+			return nil, ErrColumnOrderOutOfRange
+		}
+		if placed[f.order] {
+			return nil, ErrDuplicateColumnOrder
+		}
+		sorted[f.order] = f
+		placed[f.order] = true
+	}
+
+	next := 0
+	for _, f := range fields {
+		if f.order >= 0 {
+			continue
+		}
+		for placed[next] {
+			next++
+		}
+		sorted[next] = f
+		placed[next] = true
+	}
+
+	return sorted, nil
+}
+
+// buildPositionalHeader produces a header for documents without a header row by placing each field name at its
+// column position. Columns not bound to a field are left empty.
+func buildPositionalHeader(fields []structField) ([]string, error) {
+	width := 0
+	for _, f := range fields {
+		if f.position < 0 {
+			return nil, ErrStructTagNoPosition
+		}
+		width = max(width, f.position+1)
+	}
+
+	header := make([]string, width)
+	for _, f := range fields {
+		if header[f.position] != "" {
+			return nil, ErrDuplicateColumnPosition
 		}
+		header[f.position] = f.name
 	}
 
-	return fieldIndexes, nil
+	return header, nil
 }
@@ -0,0 +1,35 @@
+package csvdoc_test
+
+import (
+	"testing"
+)
+
+type tagGrammarRow struct {
+	ReadOnly  string `csv:"readOnly,"`
+	Both      string `csv:"both"`
+	Renamed   string `csv:"in,out"`
+	Optional  string `csv:"opt,opt,optional"`
+	OptRead   string `csv:"optRead,,optional"`
+	Unknown   string `csv:"unknown,unknown,someflag"`
+	WriteOnly string `csv:"-,writeOnly"`
+}
+
+func TestTagGrammar(t *testing.T) {
+	rows, err := readAll[tagGrammarRow](t, "readOnly,both,in,unknown,writeOnly\nr,b,i,u,w\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := tagGrammarRow{ReadOnly: "r", Both: "b", Renamed: "i", Unknown: "u"}
+	if len(rows) != 1 || *rows[0] != want {
+		t.Fatalf("got %+v, want %+v", rows, want)
+	}
+
+	rows[0].Optional, rows[0].OptRead, rows[0].WriteOnly = "o", "x", "w"
+	got, err := writeRows(t, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "both,out,opt,unknown,writeOnly\nb,i,o,u,w\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+package csvdoc
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+)
+
+// ErrorPolicy controls how readers handle rows that cannot be converted into T.
+type ErrorPolicy uint8
+
+const (
+	// ErrorPolicyFailFast returns the first row error from Read, this is the default.
+	ErrorPolicyFailFast ErrorPolicy = iota
+	// ErrorPolicySkip skips rows that fail to convert.
+	ErrorPolicySkip
+	// ErrorPolicyCollect skips rows that fail to convert and keeps their errors.
+	ErrorPolicyCollect
+)
+
+// rowErrors applies the ErrorPolicy of a reader to failed rows. It counts skipped rows, collects their errors and
+// forwards the raw records to the reject sink.
+type rowErrors struct {
+	opts      *ReaderOption
+	cw        *csv.Writer
+	header    []string
+	collected []*ParseError
+	skipped   int
+}
+
+// newRowErrors creates the row error handling of a reader. header is written to the reject sink, followed by an error
+// column, before the first rejected record. A nil header writes no header row.
+func newRowErrors(opts *ReaderOption, header []string) *rowErrors {
+	re := &rowErrors{opts: opts, header: header}
+	if opts.rejectWriter != nil {
+		re.cw = csv.NewWriter(opts.rejectWriter)
+		re.cw.Comma = opts.comma
+	}
+	return re
+}
+
+// handle applies the ErrorPolicy to err, the failure of record. It returns nil when the row may be skipped, otherwise
+// the error Read should return.
+func (re *rowErrors) handle(record []string, err error) error {
+	if re.opts.errorPolicy == ErrorPolicyFailFast {
+		return err
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		var cerr *csv.ParseError
+		if !errors.As(err, &cerr) {
+			return err
+		}
+		perr = &ParseError{Line: cerr.Line, Index: -1, Err: cerr}
+	}
+
+	re.skipped++
+	if re.opts.maxErrors > 0 && re.skipped > re.opts.maxErrors {
+		return fmt.Errorf("%w: %w", ErrTooManyRowErrors, perr)
+	}
+	if re.opts.errorPolicy == ErrorPolicyCollect {
+		re.collected = append(re.collected, perr)
+	}
+
+	return re.reject(record, perr)
+}
+
+// reject writes record and its error to the reject sink.
+func (re *rowErrors) reject(record []string, perr *ParseError) error {
+	if re.cw == nil {
+		return nil
+	}
+	if re.header != nil {
+		err := re.cw.Write(append(append(make([]string, 0, len(re.header)+1), re.header...), "error"))
+		if err != nil {
+			return err
+		}
+		re.header = nil
+	}
+	err := re.cw.Write(append(append(make([]string, 0, len(record)+1), record...), perr.Error()))
+	if err != nil {
+		return err
+	}
+	re.cw.Flush()
+	return re.cw.Error()
+}
+
+// skippable reports if err, returned by csv.Reader.Read, is a malformed row the policy allows to skip.
+func (re *rowErrors) skippable(err error) bool {
+	var cerr *csv.ParseError
+	return re.opts.errorPolicy != ErrorPolicyFailFast && errors.As(err, &cerr)
+}
@@ -1,6 +1,10 @@
 package csvdoc
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
 
 var (
 	// ErrStructTagNotInCSV struct tags must be found in the csv file.
@@ -23,4 +27,66 @@ var (
 
 	// ErrToFewStructTags more headers were provided than struct tags available.
 	ErrToFewStructTags = errors.New("to few struct tags")
+
+	// ErrResetNotSupported the reader's source does not implement io.Seeker and cannot be reset.
+	ErrResetNotSupported = errors.New("reset not supported by source")
+
+	// ErrInvalidStructTag a csvdoc struct tag could not be parsed.
+	ErrInvalidStructTag = errors.New("invalid struct tag")
+
+	// ErrDuplicateColumnOrder struct has 2 fields with the same csvorder tag.
+	ErrDuplicateColumnOrder = errors.New("duplicate column order")
+
+	// ErrColumnOrderOutOfRange a csvorder tag is not smaller than the number of written columns.
+	ErrColumnOrderOutOfRange = errors.New("column order out of range")
+
+	// ErrStructTagNoPosition headerless documents require every field to have a csvidx tag or a "#N" csv tag.
+	ErrStructTagNoPosition = errors.New("struct tag has no column position")
+
+	// ErrDuplicateColumnPosition struct has 2 fields bound to the same column position.
+	ErrDuplicateColumnPosition = errors.New("duplicate column position")
+
+	// ErrAmbiguousHeaderInCSV 2 csv headers or struct tags are the same after header normalization.
+	ErrAmbiguousHeaderInCSV = errors.New("ambiguous header in csv")
+
+	// ErrMultipleAliasesInCSV csv had columns for more than one alias of the same struct field.
+	ErrMultipleAliasesInCSV = errors.New("multiple aliases in csv")
+
+	// ErrTooManyRowErrors more rows failed than allowed by WithMaxErrors.
+	ErrTooManyRowErrors = errors.New("too many row errors")
+
+	// ErrReaderStarted the reader configuration cannot change after the first Read.
+	ErrReaderStarted = errors.New("reader already started")
+
+	// ErrChunkingNotSupported the document cannot be split into chunks when lazy quotes or comments are enabled.
+	ErrChunkingNotSupported = errors.New("lazy quotes and comments are not supported by chunked reading")
+
+	// ErrMissingColumn a record is too short to hold the column of a field that is neither optional nor read leniently.
+	ErrMissingColumn = errors.New("column missing from record")
+
+	// ErrGeneratedCodeOutdated the generated CSVRowUnmarshaler or CSVRowMarshaler methods do not match the struct fields.
+	ErrGeneratedCodeOutdated = errors.New("generated csv code does not match the struct, run csvdoc-gen again")
 )
+
+// ParseError is returned when a csv cell cannot be converted into its struct field. It wraps the conversion error so
+// errors.Is and errors.As can inspect the cause.
+type ParseError struct {
+	Type   reflect.Type // Type of the struct field
+	Err    error        // Err is the conversion error
+	Header string       // Header is the csv header (struct tag name) of the column
+	Field  string       // Field is the struct field name
+	Value  string       // Value is the raw csv cell text
+	Line   int          // Line is the physical line of the cell in the document, starting at 1
+	Index  int          // Index is the zero based column index of the cell, -1 when the whole record is malformed
+}
+
+func (e *ParseError) Error() string {
+	if e.Index < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("line %d, column %d %q (field %s %s): cannot convert %q: %v", e.Line, e.Index, e.Header, e.Field, e.Type, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,48 @@
+package csvdoc
+
+import (
+	"strings"
+	"unicode"
+)
+
+// HeaderNormalization is a set of flags controlling how csv headers and struct tag names are normalized before they are
+// matched on read. Flags can be combined with the | operator.
+type HeaderNormalization uint8
+
+const (
+	// NormalizeCase matches headers case-insensitively.
+	NormalizeCase HeaderNormalization = 1 << iota
+	// NormalizeSpace trims leading and trailing white space.
+	NormalizeSpace
+	// NormalizeBOM strips a leading UTF-8 byte order mark.
+	NormalizeBOM
+	// NormalizeSeparators treats underscores, spaces and hyphens as the same character.
+	NormalizeSeparators
+
+	// NormalizeNone matches headers exactly, this is the default.
+	NormalizeNone HeaderNormalization = 0
+	// NormalizeAll enables every normalization.
+	NormalizeAll = NormalizeCase | NormalizeSpace | NormalizeBOM | NormalizeSeparators
+)
+
+// normalize returns the key used to match name.
+func (n HeaderNormalization) normalize(name string) string {
+	if n&NormalizeBOM != 0 {
+		name = strings.TrimPrefix(name, "\uFEFF")
+	}
+	if n&NormalizeSpace != 0 {
+		name = strings.TrimSpace(name)
+	}
+	if n&NormalizeCase != 0 {
+		name = strings.ToLower(name)
+	}
+	if n&NormalizeSeparators != 0 {
+		name = strings.Map(func(r rune) rune {
+			if r == '-' || r == '_' || unicode.IsSpace(r) {
+				return '_'
+			}
+			return r
+		}, name)
+	}
+	return name
+}
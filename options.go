@@ -1,26 +1,45 @@
 package csvdoc
 
+import "io"
+
 const (
-	defaultEnableCLRF  = false
-	defaultWriteHeader = true
-	defaultEscapeRune  = ','
+	defaultEnableCLRF      = false
+	defaultWriteHeader     = true
+	defaultEscapeRune      = ','
+	defaultCloseDest       = false
+	defaultComma           = ','
+	defaultReadHeader      = true
+	defaultReuseRecord     = true
+	defaultPreserveOrder   = true
+	defaultBufferPerWorker = 64
+	defaultChunkSize       = 1 << 20
 )
 
-type Option[T WriterOption] func(*T)
+// optionType constrains Option to the supported option structs.
+type optionType interface {
+	WriterOption | ReaderOption
+}
+
+type Option[T optionType] func(*T)
 
 type WriterOption struct {
-	crlfEnable   bool
-	escapeRune   rune
-	outputHeader []string
-	writeHeader  bool
+	nullToken        string
+	outputHeader     []string
+	escapeRune       rune
+	crlfEnable       bool
+	writeHeader      bool
+	closeDestination bool
+	positional       bool
+	stringer         bool
 }
 
 func DefaultWriterOption() *WriterOption {
 	return &WriterOption{
-		crlfEnable:   defaultEnableCLRF,
-		writeHeader:  defaultWriteHeader,
-		escapeRune:   defaultEscapeRune,
-		outputHeader: nil,
+		crlfEnable:       defaultEnableCLRF,
+		writeHeader:      defaultWriteHeader,
+		escapeRune:       defaultEscapeRune,
+		outputHeader:     nil,
+		closeDestination: defaultCloseDest,
 	}
 }
 
@@ -61,3 +80,293 @@ func WithWriteHeader[T WriterOption](enable bool) Option[T] {
 		}
 	}
 }
+
+// WithPositionalColumns writes each field at the column given by its csvidx tag or "#N" csv tag and disables the
+// header row. Columns not bound to a field are left empty.
+func WithPositionalColumns[T WriterOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *WriterOption:
+			x.positional = enable
+		}
+	}
+}
+
+// WithCloseDestination hands ownership of the io.Writer given to NewWriter to the writer, so Close also closes it
+// when it implements io.Closer.
+func WithCloseDestination[T WriterOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *WriterOption:
+			x.closeDestination = enable
+		}
+	}
+}
+
+// WithStringer writes fields without a default converter or encoding.TextMarshaler using their fmt.Stringer.
+func WithStringer[T WriterOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *WriterOption:
+			x.stringer = enable
+		}
+	}
+}
+
+// WithNullToken writes nil pointers, invalid sql.Null values and driver.Valuer values that are nil as token instead of
+// an empty cell, for example \N for MySQL LOAD DATA. A csvnull tag overrides it with its first token.
+func WithNullToken[T WriterOption](token string) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *WriterOption:
+			x.nullToken = token
+		}
+	}
+}
+
+// ReaderOption holds the configuration of the underlying csv.Reader and the csvdoc specific reader settings.
+type ReaderOption struct {
+	rejectWriter        io.Writer
+	converters          map[string]Conversion
+	nullTokens          []string
+	chunkSize           int64
+	sampleRows          int
+	fieldsPerRecord     int
+	maxErrors           int
+	workers             int
+	bufferSize          int
+	comma               rune
+	comment             rune
+	errorPolicy         ErrorPolicy
+	lazyQuotes          bool
+	trimLeadingSpace    bool
+	reuseRecord         bool
+	closeSource         bool
+	readHeader          bool
+	lenientHeaders      bool
+	preserveOrder       bool
+	headerNormalization HeaderNormalization
+}
+
+func DefaultReaderOption() *ReaderOption {
+	return &ReaderOption{
+		comma:         defaultComma,
+		readHeader:    defaultReadHeader,
+		reuseRecord:   defaultReuseRecord,
+		preserveOrder: defaultPreserveOrder,
+	}
+}
+
+// WithComma sets the field delimiter of the csv.Reader (csv.Reader.Comma).
+func WithComma[T ReaderOption](comma rune) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.comma = comma
+		}
+	}
+}
+
+// WithComment sets the comment character of the csv.Reader (csv.Reader.Comment).
+func WithComment[T ReaderOption](comment rune) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.comment = comment
+		}
+	}
+}
+
+// WithFieldsPerRecord sets the expected number of fields per record of the csv.Reader (csv.Reader.FieldsPerRecord).
+func WithFieldsPerRecord[T ReaderOption](fieldsPerRecord int) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.fieldsPerRecord = fieldsPerRecord
+		}
+	}
+}
+
+// WithLazyQuotes allows quotes in unquoted fields and non-doubled quotes in quoted fields (csv.Reader.LazyQuotes).
+func WithLazyQuotes[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.lazyQuotes = enable
+		}
+	}
+}
+
+// WithTrimLeadingSpace ignores leading white space in fields (csv.Reader.TrimLeadingSpace).
+func WithTrimLeadingSpace[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.trimLeadingSpace = enable
+		}
+	}
+}
+
+// WithReuseRecord lets the csv.Reader reuse its record slice between reads (csv.Reader.ReuseRecord). It is enabled
+// by default since readers never keep the record after converting it.
+func WithReuseRecord[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.reuseRecord = enable
+		}
+	}
+}
+
+// WithReadConverter registers a custom Conversion for header at construction, the same as calling AddConverter.
+func WithReadConverter[T ReaderOption](header string, handler Conversion) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			if x.converters == nil {
+				x.converters = make(map[string]Conversion, 1)
+			}
+			x.converters[header] = handler
+		}
+	}
+}
+
+// WithCloseSource hands ownership of the io.Reader given to NewReader to the reader, so Close and the end of the
+// document also close it when it implements io.Closer.
+func WithCloseSource[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.closeSource = enable
+		}
+	}
+}
+
+// WithReadHeader controls whether the first row of the document is a header. When disabled fields are bound by the
+// column position given by their csvidx tag or "#N" csv tag.
+func WithReadHeader[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.readHeader = enable
+		}
+	}
+}
+
+// WithLenientHeaders allows any struct tagged column to be missing from the document header. Fields of missing
+// columns are left at their zero value, StreamReader.BoundFields reports the fields that were bound.
+func WithLenientHeaders[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.lenientHeaders = enable
+		}
+	}
+}
+
+// WithHeaderNormalization sets how document headers and struct tag names are normalized before they are matched.
+func WithHeaderNormalization[T ReaderOption](normalization HeaderNormalization) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.headerNormalization = normalization
+		}
+	}
+}
+
+// WithNullTokens reads cells matching one of tokens as blank cells for fields holding a null: pointers, sql.Null
+// types and other sql.Scanner implementations. A csvnull tag of a field, like `csvnull:"NULL|N/A"`, overrides tokens
+// for its column. Custom converters receive the cell unchanged.
+func WithNullTokens[T ReaderOption](tokens ...string) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.nullTokens = tokens
+		}
+	}
+}
+
+// WithErrorPolicy sets how rows that fail to convert are handled, see ErrorPolicy.
+func WithErrorPolicy[T ReaderOption](policy ErrorPolicy) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.errorPolicy = policy
+		}
+	}
+}
+
+// WithMaxErrors limits the number of rows skipped by ErrorPolicySkip and ErrorPolicyCollect. Read returns an error
+// wrapping ErrTooManyRowErrors once more than maxErrors rows failed. Zero means no limit.
+func WithMaxErrors[T ReaderOption](maxErrors int) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.maxErrors = maxErrors
+		}
+	}
+}
+
+// WithRejectWriter forwards the raw records of skipped rows to w as csv, with an additional error column holding
+// the reason the row was rejected. The document header is written first when the document has one.
+func WithRejectWriter[T ReaderOption](w io.Writer) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.rejectWriter = w
+		}
+	}
+}
+
+// WithWorkers sets the number of goroutines converting records of a ParallelReader or parsing chunks of a
+// ChunkedReader. Zero or less uses GOMAXPROCS.
+func WithWorkers[T ReaderOption](workers int) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.workers = workers
+		}
+	}
+}
+
+// WithPreserveOrder controls whether a ParallelReader returns rows in document order, enabled by default. Disabling
+// it returns rows as soon as they are converted.
+func WithPreserveOrder[T ReaderOption](enable bool) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.preserveOrder = enable
+		}
+	}
+}
+
+// WithBufferSize limits how many rows a ParallelReader reads ahead of the caller. Zero or less uses 64 rows per worker.
+func WithBufferSize[T ReaderOption](rows int) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.bufferSize = rows
+		}
+	}
+}
+
+// WithChunkSize sets the approximate number of bytes parsed by each goroutine of a ChunkedReader. Zero or less uses
+// 1 MiB.
+func WithChunkSize[T ReaderOption](bytes int64) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.chunkSize = bytes
+		}
+	}
+}
+
+// WithSampleRows limits InferSchema to the first rows rows of the document. Zero or less scans every row.
+func WithSampleRows[T ReaderOption](rows int) Option[T] {
+	return func(o *T) {
+		switch x := any(o).(type) {
+		case *ReaderOption:
+			x.sampleRows = rows
+		}
+	}
+}
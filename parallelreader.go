@@ -0,0 +1,324 @@
+package csvdoc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ParallelReader is a generic CSV document reader that reads raw records on one goroutine and converts them into
+// structs of type T on several worker goroutines. Rows are returned in document order unless WithPreserveOrder(false)
+// is provided. At most the configured buffer size of rows are read ahead of the caller.
+type ParallelReader[T any] struct {
+	readErr error
+	sr      *StreamReader[T]
+	jobs    chan parallelJob
+	results chan parallelResult[T]
+	window  chan struct{}
+	done    chan struct{}
+	pending map[uint64]parallelResult[T]
+	columns []readColumn
+	wg      sync.WaitGroup
+	next    uint64
+	line    int
+	started bool
+	closed  bool
+}
+
+// parallelJob is a raw record read by the producer goroutine. err is set for malformed records.
+type parallelJob struct {
+	err    error
+	record []string
+	seq    uint64
+	line   int
+}
+
+// parallelResult is a record converted by a worker goroutine.
+type parallelResult[T any] struct {
+	err    error
+	t      *T
+	record []string
+	seq    uint64
+	line   int
+}
+
+// NewParallelReader creates a new CSV ParallelReader reading from r. The header is read and bound the same way as
+// NewReader, the conversion workers start on the first Read so converters can still be added. The caller remains
+// responsible for closing r unless WithCloseSource(true) is provided.
+func NewParallelReader[T any](r io.Reader, opts ...Option[ReaderOption]) (*ParallelReader[T], error) {
+	plan := planFor[T]()
+	if plan.readErr != nil {
+		return nil, plan.readErr
+	}
+
+	ro := applyReaderOptions(opts)
+	// records are handed to other goroutines so the csv.Reader must not reuse them.
+	ro.reuseRecord = false
+	var closer io.Closer
+	if c, ok := r.(io.Closer); ok && ro.closeSource {
+		closer = c
+	}
+
+	sr, err := newStreamReader[T](r, closer, plan, ro)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParallelReader[T]{sr: sr}, nil
+}
+
+// start launches the producer and worker goroutines.
+func (pr *ParallelReader[T]) start() {
+	workers := pr.sr.opts.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	buffer := pr.sr.opts.bufferSize
+	if buffer <= 0 {
+		buffer = workers * defaultBufferPerWorker
+	}
+
+	pr.started = true
+	pr.columns = append([]readColumn(nil), pr.sr.columns...)
+	pr.jobs = make(chan parallelJob, buffer)
+	pr.results = make(chan parallelResult[T], buffer)
+	pr.window = make(chan struct{}, buffer)
+	pr.done = make(chan struct{})
+	pr.pending = make(map[uint64]parallelResult[T])
+
+	pr.wg.Add(1)
+	go pr.produce()
+
+	var workerWg sync.WaitGroup
+	workerWg.Add(workers)
+	for range workers {
+		go func() {
+			defer workerWg.Done()
+			pr.work()
+		}()
+	}
+
+	pr.wg.Add(1)
+	go func() {
+		defer pr.wg.Done()
+		workerWg.Wait()
+		close(pr.results)
+	}()
+}
+
+// produce reads raw records until the end of the document, an error or Close. The error ending the document is kept
+// in readErr and returned by Read once all rows before it were returned.
+func (pr *ParallelReader[T]) produce() {
+	defer pr.wg.Done()
+	defer close(pr.jobs)
+
+	var seq uint64
+	for {
+		record, err := pr.sr.cr.Read()
+		if err != nil && !pr.sr.rowErrors.skippable(err) {
+			pr.readErr = err
+			return
+		}
+
+		select {
+		case pr.window <- struct{}{}:
+		case <-pr.done:
+			return
+		}
+		job := parallelJob{record: record, err: err, seq: seq}
+		if err == nil {
+			job.line, _ = pr.sr.cr.FieldPos(0)
+		}
+		select {
+		case pr.jobs <- job:
+		case <-pr.done:
+			return
+		}
+		seq++
+	}
+}
+
+// work converts jobs into results until the jobs channel is closed or the reader is closed.
+func (pr *ParallelReader[T]) work() {
+	dec := newRowDecoder(pr.columns, pr.sr.generated)
+	for job := range pr.jobs {
+		res := parallelResult[T]{record: job.record, seq: job.seq, line: job.line, err: job.err}
+		if job.err == nil {
+			res.t = new(T)
+			res.err = dec.decode(job.record, res.t)
+			var perr *ParseError
+			if errors.As(res.err, &perr) {
+				perr.Line = recordLine(job.line, job.record, min(perr.Index, len(job.record)-1))
+			}
+		}
+
+		select {
+		case pr.results <- res:
+		case <-pr.done:
+			return
+		}
+	}
+}
+
+// recordLine returns the document line of column col of a record starting at line start, counting the new lines
+// of the quoted cells before it.
+func recordLine(start int, record []string, col int) int {
+	for _, cell := range record[:col] {
+		start += strings.Count(cell, "\n")
+	}
+	return start
+}
+
+// receive returns the next result to hand to the caller, in document order when order is preserved. It returns false
+// once every result was received and ctx.Err() once ctx is done, also for rows skipped by the ErrorPolicy.
+func (pr *ParallelReader[T]) receive(ctx context.Context) (parallelResult[T], bool, error) {
+	// checked first as select picks a ready result over a done ctx at random.
+	if ctx.Err() != nil {
+		return parallelResult[T]{}, false, ctx.Err()
+	}
+	for {
+		if res, ok := pr.pending[pr.next]; ok {
+			delete(pr.pending, pr.next)
+			pr.next++
+			return res, true, nil
+		}
+
+		select {
+		case res, ok := <-pr.results:
+			if !ok {
+				return res, false, nil
+			}
+			if !pr.sr.opts.preserveOrder || res.seq == pr.next {
+				pr.next++
+				return res, true, nil
+			}
+			pr.pending[res.seq] = res
+		case <-ctx.Done():
+			return parallelResult[T]{}, false, ctx.Err()
+		}
+	}
+}
+
+// Read returns the next converted row. Returns EOF and closes an owned source automatically. Rows that fail to
+// convert are skipped when an ErrorPolicy other than ErrorPolicyFailFast is configured.
+func (pr *ParallelReader[T]) Read() (*T, error) {
+	return pr.read(context.Background())
+}
+
+// ReadContext is Read that stops once ctx is done, including while waiting for the workers. The reader is closed and
+// the returned error wraps ctx.Err() with the line the last row read starts on.
+func (pr *ParallelReader[T]) ReadContext(ctx context.Context) (*T, error) {
+	if ctx.Err() != nil {
+		cerr := pr.Close()
+		if cerr != nil {
+			log.Println("Error closeing file: ", cerr)
+		}
+		return nil, fmt.Errorf("read cancelled after line %d: %w", pr.line, ctx.Err())
+	}
+	return pr.read(ctx)
+}
+
+func (pr *ParallelReader[T]) read(ctx context.Context) (*T, error) {
+	if pr.closed {
+		if pr.readErr != nil {
+			return nil, pr.readErr
+		}
+		return nil, io.EOF
+	}
+	if !pr.started {
+		pr.start()
+	}
+
+	for {
+		res, ok, err := pr.receive(ctx)
+		if err != nil {
+			cerr := pr.Close()
+			if cerr != nil {
+				log.Println("Error closeing file: ", cerr)
+			}
+			return nil, fmt.Errorf("read cancelled after line %d: %w", pr.line, err)
+		}
+		if !ok {
+			cerr := pr.Close()
+			if cerr != nil {
+				log.Println("Error closeing file: ", cerr)
+			}
+			return nil, pr.readErr
+		}
+		<-pr.window
+
+		if res.err == nil {
+			pr.line = res.line
+			return res.t, nil
+		}
+		err = pr.sr.rowErrors.handle(res.record, res.err)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close stops the producer and worker goroutines and closes the underlying source if it is owned by the reader. Close
+// waits for the producer goroutine, a source that is not owned and blocks in Read delays Close until the read returns.
+func (pr *ParallelReader[T]) Close() error {
+	if !pr.closed {
+		pr.closed = true
+		if pr.started {
+			close(pr.done)
+		}
+	}
+	err := pr.sr.Close()
+	if pr.started {
+		pr.wg.Wait()
+	}
+	return err
+}
+
+// Reset is not supported by a ParallelReader and returns ErrResetNotSupported.
+func (pr *ParallelReader[T]) Reset() error {
+	return ErrResetNotSupported
+}
+
+// All returns an iterator over the remaining rows for use with range, see All.
+func (pr *ParallelReader[T]) All() iter.Seq2[*T, error] {
+	return All[T](pr)
+}
+
+// AddConverter adds a customer Conversion func to handle a specific CSV header/struct tag. Converters must be added
+// before the first Read, afterwards ErrReaderStarted is returned.
+func (pr *ParallelReader[T]) AddConverter(header string, handler Conversion) error {
+	if pr.started {
+		return ErrReaderStarted
+	}
+	return pr.sr.AddConverter(header, handler)
+}
+
+// RemoveConverter removes a customer Conversion func for a specific CSV header/struct tag. Converters must be removed
+// before the first Read, afterwards ErrReaderStarted is returned.
+func (pr *ParallelReader[T]) RemoveConverter(header string) error {
+	if pr.started {
+		return ErrReaderStarted
+	}
+	return pr.sr.RemoveConverter(header)
+}
+
+// BoundFields returns the names of the struct fields of T that were bound to a csv column, see StreamReader.BoundFields.
+func (pr *ParallelReader[T]) BoundFields() []string {
+	return pr.sr.BoundFields()
+}
+
+// Errors returns the errors of the rows skipped under ErrorPolicyCollect.
+func (pr *ParallelReader[T]) Errors() []*ParseError {
+	return pr.sr.Errors()
+}
+
+// SkippedRows returns the number of rows skipped under ErrorPolicySkip or ErrorPolicyCollect.
+func (pr *ParallelReader[T]) SkippedRows() int {
+	return pr.sr.SkippedRows()
+}
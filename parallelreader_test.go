@@ -0,0 +1,292 @@
+package csvdoc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+type numberedRow struct {
+	Name string `csv:"name"`
+	Note string `csv:"note"`
+	ID   int    `csv:"id"`
+}
+
+// numberedDocument returns a document of n rows. Some notes hold quoted new lines, bad returns the id cell of a row
+// and may make it fail to convert or be malformed.
+func numberedDocument(n int, bad func(i int) string) string {
+	var b strings.Builder
+	b.WriteString("id,name,note\n")
+	for i := range n {
+		id := fmt.Sprint(i)
+		if bad != nil {
+			id = bad(i)
+		}
+		note := "plain"
+		if i%7 == 0 {
+			note = fmt.Sprintf("\"line\nbreak %d\"", i)
+		}
+		fmt.Fprintf(&b, "%s,name %d,%s\n", id, i, note)
+	}
+	return b.String()
+}
+
+// compareWithStreamReader reads doc with a StreamReader and a ParallelReader using opts and compares their rows,
+// errors and skipped rows.
+func compareWithStreamReader(t *testing.T, doc string, opts ...csvdoc.Option[csvdoc.ReaderOption]) {
+	t.Helper()
+	sr, err := csvdoc.NewReader[numberedRow](strings.NewReader(doc), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, wantErr := collect[numberedRow](sr)
+
+	pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(doc), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, gotErr := collect[numberedRow](pr)
+	if cerr := pr.Close(); cerr != nil {
+		t.Fatal(cerr)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got %d rows, want %d rows in the same order", len(got), len(want))
+	}
+	if errorString(gotErr) != errorString(wantErr) {
+		t.Errorf("got error %v, want %v", gotErr, wantErr)
+	}
+	if pr.SkippedRows() != sr.SkippedRows() {
+		t.Errorf("got %d skipped rows, want %d", pr.SkippedRows(), sr.SkippedRows())
+	}
+	gotErrs, wantErrs := pr.Errors(), sr.Errors()
+	if len(gotErrs) != len(wantErrs) {
+		t.Fatalf("got %d collected errors, want %d", len(gotErrs), len(wantErrs))
+	}
+	for i := range gotErrs {
+		if gotErrs[i].Error() != wantErrs[i].Error() || gotErrs[i].Line != wantErrs[i].Line {
+			t.Errorf("collected error %d: got %v on line %d, want %v on line %d", i, gotErrs[i], gotErrs[i].Line,
+				wantErrs[i], wantErrs[i].Line)
+		}
+	}
+}
+
+func TestParallelReaderPreservesOrder(t *testing.T) {
+	checkGoroutines(t)
+	doc := numberedDocument(3000, nil)
+	for _, workers := range []int{1, 2, 8, 32} {
+		for _, buffer := range []int{1, 3, 0} {
+			t.Run(fmt.Sprintf("workers %d buffer %d", workers, buffer), func(t *testing.T) {
+				compareWithStreamReader(t, doc, csvdoc.WithWorkers(workers), csvdoc.WithBufferSize(buffer))
+			})
+		}
+	}
+}
+
+func TestParallelReaderUnordered(t *testing.T) {
+	checkGoroutines(t)
+	doc := numberedDocument(3000, nil)
+	sr, err := csvdoc.NewReader[numberedRow](strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := collect[numberedRow](sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(doc), csvdoc.WithWorkers(8),
+		csvdoc.WithPreserveOrder(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := collect[numberedRow](pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := func(a, b numberedRow) int { return a.ID - b.ID }
+	slices.SortFunc(got, byID)
+	slices.SortFunc(want, byID)
+	if !slices.Equal(got, want) {
+		t.Errorf("got %d rows, want the same %d rows in any order", len(got), len(want))
+	}
+}
+
+func TestParallelReaderErrorPolicy(t *testing.T) {
+	checkGoroutines(t)
+	rng := rand.New(rand.NewPCG(1, 2))
+	bad := func(i int) string {
+		switch rng.IntN(20) {
+		case 0:
+			return "x" + fmt.Sprint(i)
+		case 1:
+			return fmt.Sprintf("%d\"bare", i)
+		}
+		return fmt.Sprint(i)
+	}
+	doc := numberedDocument(2000, bad)
+
+	policies := []struct {
+		name string
+		opts []csvdoc.Option[csvdoc.ReaderOption]
+	}{
+		{name: "fail fast"},
+		{name: "skip", opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithErrorPolicy(csvdoc.ErrorPolicySkip)}},
+		{name: "collect", opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithErrorPolicy(csvdoc.ErrorPolicyCollect)}},
+		{name: "max errors", opts: []csvdoc.Option[csvdoc.ReaderOption]{
+			csvdoc.WithErrorPolicy(csvdoc.ErrorPolicyCollect), csvdoc.WithMaxErrors(50),
+		}},
+	}
+	for _, p := range policies {
+		for _, workers := range []int{1, 4, 16} {
+			t.Run(fmt.Sprintf("%s workers %d", p.name, workers), func(t *testing.T) {
+				compareWithStreamReader(t, doc, append(p.opts, csvdoc.WithWorkers(workers))...)
+			})
+		}
+	}
+}
+
+func TestParallelReaderClose(t *testing.T) {
+	checkGoroutines(t)
+	doc := numberedDocument(500, nil)
+
+	t.Run("before start", func(t *testing.T) {
+		pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = pr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pr.Read()
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Read after Close: got %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("after start", func(t *testing.T) {
+		pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(doc), csvdoc.WithBufferSize(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 10 {
+			_, err = pr.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = pr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = pr.Close()
+		if err != nil {
+			t.Fatalf("second Close: %v", err)
+		}
+		_, err = pr.Read()
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Read after Close: got %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("break out of All", func(t *testing.T) {
+		pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(doc), csvdoc.WithBufferSize(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rerr := range pr.All() {
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			break
+		}
+	})
+}
+
+func TestParallelReaderConvertersBeforeStart(t *testing.T) {
+	checkGoroutines(t)
+	pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(numberedDocument(5, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.Close()
+
+	err = pr.AddConverter("id", csvdoc.Conversion(func(_ string, field *reflect.Value) error {
+		field.SetInt(7)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := pr.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID != 7 {
+		t.Errorf("got id %d, want the converted 7", row.ID)
+	}
+	if err = pr.AddConverter("name", nil); !errors.Is(err, csvdoc.ErrReaderStarted) {
+		t.Errorf("AddConverter after start: got %v, want %v", err, csvdoc.ErrReaderStarted)
+	}
+	if err = pr.RemoveConverter("id"); !errors.Is(err, csvdoc.ErrReaderStarted) {
+		t.Errorf("RemoveConverter after start: got %v, want %v", err, csvdoc.ErrReaderStarted)
+	}
+}
+
+func TestParallelReaderCancel(t *testing.T) {
+	checkGoroutines(t)
+	doc := numberedDocument(5000, nil)
+
+	t.Run("while reading", func(t *testing.T) {
+		pr, err := csvdoc.NewParallelReader[numberedRow](strings.NewReader(doc), csvdoc.WithBufferSize(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for range 100 {
+			_, err = pr.ReadContext(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		cancel()
+		_, err = pr.ReadContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want %v", err, context.Canceled)
+		}
+		_, err = pr.Read()
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Read after cancel: got %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("while skipping", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		src := &lineSource{cancel: cancel, after: 10, lines: []string{"id,name,note\n"}}
+		for i := range 1000 {
+			src.lines = append(src.lines, fmt.Sprintf("bad%d,n,x\n", i))
+		}
+		src.lines = append(src.lines, "1,n,x\n")
+
+		pr, err := csvdoc.NewParallelReader[numberedRow](src, csvdoc.WithErrorPolicy(csvdoc.ErrorPolicySkip),
+			csvdoc.WithBufferSize(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		row, err := pr.ReadContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, %v, want %v", row, err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package csvdoc
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// typePlan is the reflection work for a struct type shared by every reader and writer of that type. The csv tags are
+// parsed and the default converters of every field resolved once per type.
+type typePlan struct {
+	readErr        error
+	writeErr       error
+	readFields     []structField
+	writeFields    []structField
+	generatedRead  bool
+	generatedWrite bool
+}
+
+//nolint:gochecknoglobals // Plans are immutable once built and shared by all readers and writers, keyed by reflect.Type.
+var typePlans sync.Map
+
+//nolint:gochecknoglobals // Default converters are stateless and shared by all plans.
+var (
+	readDefaultConverters  = sync.OnceValue(buildReadDefaultConverters)
+	writeDefaultConverters = sync.OnceValue(buildWriteDefaultConverters)
+)
+
+// planFor returns the cached typePlan of T, building it on first use.
+func planFor[T any]() *typePlan {
+	rt := reflect.TypeFor[T]()
+	if cached, ok := typePlans.Load(rt); ok {
+		plan, _ := cached.(*typePlan)
+		return plan
+	}
+
+	cached, _ := typePlans.LoadOrStore(rt, buildTypePlan(rt))
+	plan, _ := cached.(*typePlan)
+	return plan
+}
+
+// buildTypePlan parses the csv tags of the struct type rt and resolves the default converter of each field, time tags on
+// fields other than time.Time and sql.NullTime, or pointers and sql.Null of them, are invalid. Generated
+// CSVRowUnmarshaler and CSVRowMarshaler methods of *rt are checked against the fields once here.
+func buildTypePlan(rt reflect.Type) *typePlan {
+	plan := &typePlan{}
+	plan.readFields, plan.readErr = buildReflectFieldCache(rt, false)
+	for i := range plan.readFields {
+		f := &plan.readFields[i]
+		f.conv = readConverter(f.typ)
+		f.nullable = nullableType(f.typ)
+		if f.time != nil {
+			f.conv = timeConverter(f.typ, f.time)
+			if f.conv == nil && plan.readErr == nil {
+				plan.readErr = ErrInvalidStructTag
+			}
+		}
+	}
+	if u, ok := reflect.New(rt).Interface().(CSVRowUnmarshaler); ok && plan.readErr == nil {
+		d := &RowDecoder{probing: true}
+		plan.readErr = u.UnmarshalCSVRow(d)
+		if plan.readErr == nil {
+			plan.readErr = checkGenerated(rt, plan.readFields, d.probed)
+		}
+		plan.generatedRead = true
+	}
+
+	plan.writeFields, plan.writeErr = buildReflectFieldCache(rt, true)
+	for i := range plan.writeFields {
+		f := &plan.writeFields[i]
+		f.toString = writeConverter(f.typ)
+		f.stringer = stringerConverter(f.typ)
+		if f.time != nil {
+			f.toString = timeToString(f.typ, f.time)
+			if f.toString == nil && plan.writeErr == nil {
+				plan.writeErr = ErrInvalidStructTag
+			}
+		}
+	}
+	if m, ok := reflect.New(rt).Interface().(CSVRowMarshaler); ok && plan.writeErr == nil {
+		e := &RowEncoder{probing: true}
+		plan.writeErr = m.MarshalCSVRow(e)
+		if plan.writeErr == nil {
+			plan.writeErr = checkGenerated(rt, plan.writeFields, e.probed)
+		}
+		plan.generatedWrite = true
+	}
+
+	return plan
+}
+
+// readColumn binds a csv column to the struct field its cells are converted into. custom is set while conv is a
+// converter added to the reader instead of the default of the field. Cells matching one of nulls are read as blank
+// cells. Records too short to hold a required column fail with ErrMissingColumn.
+type readColumn struct {
+	conv     Conversion
+	field    *structField
+	nulls    []string
+	col      int
+	custom   bool
+	required bool
+}
+
+// writeColumn binds a struct field to the csv column it is written to. custom is set while toString is a converter
+// added to the writer instead of the default of the field. null is written for nil and invalid values.
+type writeColumn struct {
+	toString ToStringConversion
+	field    *structField
+	null     string
+	col      int
+	custom   bool
+}
+
+// buildReadColumns binds fields to the columns in nameIndex, sorted by column. Fields missing from nameIndex are not
+// bound. Nullable fields use the null tokens of their csvnull tag, otherwise the null tokens of opts. Columns are
+// required unless the field is optional or lenient header matching is enabled.
+func buildReadColumns(fields []structField, nameIndex map[string]int, opts *ReaderOption) []readColumn {
+	columns := make([]readColumn, 0, len(nameIndex))
+	for i := range fields {
+		if col, ok := nameIndex[fields[i].name]; ok {
+			c := readColumn{conv: fields[i].conv, field: &fields[i], col: col}
+			c.required = !fields[i].optional && !opts.lenientHeaders
+			if fields[i].nullable {
+				c.nulls = opts.nullTokens
+				if fields[i].nullTokens != nil {
+					c.nulls = fields[i].nullTokens
+				}
+			}
+			columns = append(columns, c)
+		}
+	}
+	slices.SortFunc(columns, func(a, b readColumn) int {
+		return cmp.Compare(a.col, b.col)
+	})
+	return columns
+}
+
+// buildWriteColumns binds fields to the columns in nameIndex, sorted by column. Fields missing from nameIndex are not
+// bound. Nil and invalid values are written as the first null token of the csvnull tag of the field, otherwise as
+// the null token of opts.
+func buildWriteColumns(fields []structField, nameIndex map[string]int, opts *WriterOption) []writeColumn {
+	columns := make([]writeColumn, 0, len(nameIndex))
+	for i := range fields {
+		if col, ok := nameIndex[fields[i].name]; ok {
+			c := writeColumn{toString: defaultToString(&fields[i], opts.stringer), field: &fields[i], col: col}
+			c.null = opts.nullToken
+			if fields[i].nullTokens != nil {
+				c.null = fields[i].nullTokens[0]
+			}
+			columns = append(columns, c)
+		}
+	}
+	slices.SortFunc(columns, func(a, b writeColumn) int {
+		return cmp.Compare(a.col, b.col)
+	})
+	return columns
+}
@@ -1,8 +1,13 @@
 package csvdoc
 
 import (
+	"context"
 	"database/sql"
+	"encoding"
 	"errors"
+	"io"
+	"iter"
+	"log"
 	"reflect"
 	"strconv"
 	"strings"
@@ -24,96 +29,102 @@ type Reader[T any] interface {
 	RemoveConverter(header string) error                  // Removes a custom conversion function for the specified header.
 }
 
+// ContextReader is a Reader whose reads can be cancelled, implemented by all readers of this package.
+type ContextReader[T any] interface {
+	Reader[T]
+	ReadContext(ctx context.Context) (*T, error) // Read that closes the reader and stops once ctx is done
+}
+
+// All returns an iterator over the rows of r for use with range. Iteration stops at the end of the document or after
+// yielding the first error, io.EOF is not yielded. r is closed when the iteration ends, including when the loop
+// exits early.
+func All[T any](r Reader[T]) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		defer func() {
+			err := r.Close()
+			if err != nil {
+				log.Println("Error closeing reader: ", err)
+			}
+		}()
+		for {
+			t, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(t, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
 // buildReadDefaultConverters produces a map[reflect.Type]Conversion for each default handled type. Reader implementations can use
 // this map to aid in building default csv string values into Go types.
 func buildReadDefaultConverters() map[reflect.Type]Conversion {
 	converts := make(map[reflect.Type]Conversion)
 	intConversion := Conversion(func(s string, field *reflect.Value) error {
-		if s != "" {
-			val, err := strconv.ParseInt(s, 10, 64)
-			if err != nil {
-				return err
-			}
-			if field.OverflowInt(val) {
-				return errors.New("overflow convert")
-			}
-			field.SetInt(val)
-			return nil
+		val, err := parseInt(s)
+		if err != nil {
+			return err
 		}
-
-		return errors.New("cannot convert empty string to int*")
+		if field.OverflowInt(val) {
+			return errors.New("overflow convert")
+		}
+		field.SetInt(val)
+		return nil
 	})
 	sqlNullInt64Conversion := Conversion(func(a string, field *reflect.Value) error {
-		if a != "" {
-			val, err := strconv.ParseInt(a, 10, 64)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(sql.NullInt64{Int64: val, Valid: true}))
+		val, err := parseNullInt64(a)
+		if err != nil {
+			return err
 		}
-
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 	sqlNullInt32Conversion := Conversion(func(a string, field *reflect.Value) error {
-		if a != "" {
-			val, err := strconv.ParseInt(a, 10, 32)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(sql.NullInt32{Int32: int32(val), Valid: true}))
+		val, err := parseNullInt32(a)
+		if err != nil {
+			return err
 		}
-
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 	sqlNullInt16Conversion := Conversion(func(a string, field *reflect.Value) error {
-		if a != "" {
-			val, err := strconv.ParseInt(a, 10, 16)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(sql.NullInt16{Int16: int16(val), Valid: true}))
+		val, err := parseNullInt16(a)
+		if err != nil {
+			return err
 		}
-
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 	uintConversion := Conversion(func(s string, field *reflect.Value) error {
-		if s != "" {
-			val, err := strconv.ParseUint(s, 10, 64)
-			if err != nil {
-				return err
-			}
-			if field.OverflowUint(val) {
-				return ErrTypeOverflow
-			}
-			field.SetUint(val)
-			return nil
+		val, err := parseUint(s)
+		if err != nil {
+			return err
+		}
+		if field.OverflowUint(val) {
+			return ErrTypeOverflow
 		}
-		return errors.New("cannot convert empty string to uint*")
+		field.SetUint(val)
+		return nil
 	})
 	floatConversion := Conversion(func(s string, field *reflect.Value) error {
-		if s != "" {
-			val, err := strconv.ParseFloat(s, 64)
-			if err != nil {
-				return err
-			}
-			if field.OverflowFloat(val) {
-				return ErrTypeOverflow
-			}
-			field.SetFloat(val)
-			return nil
+		val, err := parseFloat(s)
+		if err != nil {
+			return err
 		}
-
-		return errors.New("cannot convert empty string to float*")
+		if field.OverflowFloat(val) {
+			return ErrTypeOverflow
+		}
+		field.SetFloat(val)
+		return nil
 	})
 	sqlNullFloat64Conversion := Conversion(func(s string, field *reflect.Value) error {
-		if s != "" {
-			val, err := strconv.ParseFloat(s, 64)
-			if err != nil {
-				return err
-			}
-			field.Set(reflect.ValueOf(sql.NullFloat64{Float64: val, Valid: true}))
+		val, err := parseNullFloat64(s)
+		if err != nil {
+			return err
 		}
-
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 	stringConversion := Conversion(func(s string, field *reflect.Value) error {
@@ -121,63 +132,43 @@ func buildReadDefaultConverters() map[reflect.Type]Conversion {
 		return nil
 	})
 	timeConversion := Conversion(func(s string, field *reflect.Value) error {
-		formats := []string{time.DateTime, time.DateOnly, time.RFC3339, time.RFC3339Nano, "01/02/2006 15:04:05 PM", "1/2/2006 15:04:05 PM"}
-		if s != "" {
-			for _, format := range formats {
-				val, err := time.Parse(format, s)
-				if err == nil {
-					field.Set(reflect.ValueOf(val))
-					return nil
-				}
-			}
-			return errors.New("cannot convert string to time")
+		val, err := parseTime(s)
+		if err != nil {
+			return err
 		}
-		return errors.New("cannot convert empty string to time")
+		field.Set(reflect.ValueOf(val))
+		return nil
 	})
 	boolConversion := Conversion(func(a string, field *reflect.Value) error {
-		if a != "" {
-			cmp := strings.ToLower(a)
-			if cmp == "true" || cmp == "1" || cmp == "on" || cmp == "yes" || cmp == "y" {
-				field.SetBool(true)
-			} else {
-				field.SetBool(false)
-			}
-			return nil
+		val, err := parseBool(a)
+		if err != nil {
+			return err
 		}
-
-		return errors.New("cannot convert empty string to bool")
+		field.SetBool(val)
+		return nil
 	})
 	sqlNullTimeConversion := Conversion(func(s string, field *reflect.Value) error {
-		formats := []string{time.DateTime, time.DateOnly, time.RFC3339, time.RFC3339Nano, "01/02/2006 15:04:05 PM", "1/2/2006 15:04:05 PM"}
-		if s != "" {
-			for _, format := range formats {
-				val, err := time.Parse(format, s)
-				if err == nil {
-					field.Set(reflect.ValueOf(sql.NullTime{Time: val, Valid: true}))
-					return nil
-				}
-			}
-			return errors.New("cannot convert string to time")
+		val, err := parseNullTime(s)
+		if err != nil {
+			return err
 		}
-
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 	sqlNullStringConversion := Conversion(func(a string, field *reflect.Value) error {
-		if a != "" {
-			field.Set(reflect.ValueOf(sql.NullString{String: a, Valid: true}))
+		val, err := parseNullString(a)
+		if err != nil {
+			return err
 		}
-
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 	sqlNullBoolConversion := Conversion(func(a string, field *reflect.Value) error {
-		if a != "" {
-			cmp := strings.ToLower(a)
-			if cmp == "true" || cmp == "1" || cmp == "on" || cmp == "yes" || cmp == "y" {
-				field.Set(reflect.ValueOf(sql.NullBool{Bool: true, Valid: true}))
-			} else {
-				field.Set(reflect.ValueOf(sql.NullBool{Bool: false, Valid: true}))
-			}
+		val, err := parseNullBool(a)
+		if err != nil {
+			return err
 		}
+		field.Set(reflect.ValueOf(val))
 		return nil
 	})
 
@@ -205,30 +196,287 @@ func buildReadDefaultConverters() map[reflect.Type]Conversion {
 	return converts
 }
 
-// buildHeaderNameIndexCache creates two maps representing the csv header and the column number of the header.
-// The first map links column names to their index positions, the second maps indices back to names.
-// It validates that all headers exist in struct tags and checks for duplicate headers.
-func buildReadHeaderNameIndexCache(headerLine []string, tFieldsIndexes map[string]int) (map[string]int, map[int]string, error) {
-	nameIndex := make(map[string]int, len(tFieldsIndexes))
-	indexName := make(map[int]string, len(tFieldsIndexes))
+// parseInt parses the cell of an int* field, blank cells cannot be converted.
+func parseInt(s string) (int64, error) {
+	if s == "" {
+		return 0, errors.New("cannot convert empty string to int*")
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// parseUint parses the cell of an uint* field, blank cells cannot be converted.
+func parseUint(s string) (uint64, error) {
+	if s == "" {
+		return 0, errors.New("cannot convert empty string to uint*")
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// parseFloat parses the cell of a float* field, blank cells cannot be converted.
+func parseFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, errors.New("cannot convert empty string to float*")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseBool parses the cell of a bool field. true, 1, on, yes and y in any case are true, everything else is false.
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return false, errors.New("cannot convert empty string to bool")
+	}
+	cmp := strings.ToLower(s)
+	return cmp == "true" || cmp == "1" || cmp == "on" || cmp == "yes" || cmp == "y", nil
+}
+
+// readConverter returns the Conversion of fields of type t, the default converter of t, the converter of the element
+// for pointers and of V for sql.Null[V], otherwise sql.Scanner or encoding.TextUnmarshaler when *t implements them.
+// nil is returned when t has none of them.
+func readConverter(t reflect.Type) Conversion {
+	if conv, ok := readDefaultConverters()[t]; ok {
+		return conv
+	}
+	if t.Kind() == reflect.Pointer {
+		if conv := readConverter(t.Elem()); conv != nil {
+			return pointerConversion(conv)
+		}
+		return nil
+	}
+	if v, ok := nullValueType(t); ok {
+		if conv := readConverter(v); conv != nil {
+			return nullConversion(conv)
+		}
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[sql.Scanner]()) {
+		return scan
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return unmarshalText
+	}
+	return nil
+}
+
+// nullableType reports if fields of type t hold a null for blank cells, pointers and sql.Scanner implementations like
+// the sql.Null types.
+func nullableType(t reflect.Type) bool {
+	return t.Kind() == reflect.Pointer || reflect.PointerTo(t).Implements(reflect.TypeFor[sql.Scanner]())
+}
+
+// pointerConversion returns the Conversion of a pointer field converting the pointed to value with conv. Blank cells
+// are nil, other cells are converted into a newly allocated value.
+func pointerConversion(conv Conversion) Conversion {
+	return func(s string, field *reflect.Value) error {
+		if s == "" {
+			field.SetZero()
+			return nil
+		}
+		p := reflect.New(field.Type().Elem())
+		v := p.Elem()
+		err := conv(s, &v)
+		if err != nil {
+			return err
+		}
+		field.Set(p)
+		return nil
+	}
+}
+
+// nullValueType returns V when t is sql.Null[V].
+func nullValueType(t reflect.Type) (reflect.Type, bool) {
+	//nolint:mnd // sql.Null[V] has the fields V and Valid.
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null[") ||
+		t.NumField() != 2 || t.Field(0).Name != "V" || t.Field(1).Name != "Valid" {
+		return nil, false
+	}
+	return t.Field(0).Type, true
+}
+
+// nullConversion returns the Conversion of a sql.Null[V] field converting V with conv. Blank cells are not valid.
+func nullConversion(conv Conversion) Conversion {
+	return func(s string, field *reflect.Value) error {
+		if s == "" {
+			field.SetZero()
+			return nil
+		}
+		v := field.Field(0)
+		err := conv(s, &v)
+		if err != nil {
+			return err
+		}
+		field.Field(1).SetBool(true)
+		return nil
+	}
+}
+
+// scan is the Conversion of fields implementing sql.Scanner. Cells are scanned as string, blank cells as nil.
+func scan(s string, field *reflect.Value) error {
+	scanner, ok := field.Addr().Interface().(sql.Scanner)
+	if !ok {
+		return ErrConverterNotFoundForType
+	}
+	if s == "" {
+		return scanner.Scan(nil)
+	}
+	return scanner.Scan(s)
+}
+
+// unmarshalText is the Conversion of fields implementing encoding.TextUnmarshaler, blank cells are passed on as well.
+func unmarshalText(s string, field *reflect.Value) error {
+	u, ok := field.Addr().Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return ErrConverterNotFoundForType
+	}
+	return u.UnmarshalText([]byte(s))
+}
+
+// timeLayouts returns the layouts read by the default time.Time converters, in the order they are tried.
+func timeLayouts() []string {
+	return []string{time.DateTime, time.DateOnly, time.RFC3339, time.RFC3339Nano, "01/02/2006 15:04:05 PM", "1/2/2006 15:04:05 PM"}
+}
+
+// parseTime parses the cell of a time.Time field trying each of the supported layouts.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("cannot convert empty string to time")
+	}
+	for _, format := range timeLayouts() {
+		val, err := time.Parse(format, s)
+		if err == nil {
+			return val, nil
+		}
+	}
+	return time.Time{}, errors.New("cannot convert string to time")
+}
+
+// parseNullString parses the cell of a sql.NullString field, blank cells are not valid.
+func parseNullString(s string) (sql.NullString, error) {
+	return sql.NullString{String: s, Valid: s != ""}, nil
+}
+
+// parseNullInt64 parses the cell of a sql.NullInt64 field, blank cells are not valid.
+func parseNullInt64(s string) (sql.NullInt64, error) {
+	if s == "" {
+		return sql.NullInt64{}, nil
+	}
+	val, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: val, Valid: true}, nil
+}
+
+// parseNullInt32 parses the cell of a sql.NullInt32 field, blank cells are not valid.
+func parseNullInt32(s string) (sql.NullInt32, error) {
+	if s == "" {
+		return sql.NullInt32{}, nil
+	}
+	val, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return sql.NullInt32{}, err
+	}
+	return sql.NullInt32{Int32: int32(val), Valid: true}, nil
+}
+
+// parseNullInt16 parses the cell of a sql.NullInt16 field, blank cells are not valid.
+func parseNullInt16(s string) (sql.NullInt16, error) {
+	if s == "" {
+		return sql.NullInt16{}, nil
+	}
+	val, err := strconv.ParseInt(s, 10, 16)
+	if err != nil {
+		return sql.NullInt16{}, err
+	}
+	return sql.NullInt16{Int16: int16(val), Valid: true}, nil
+}
+
+// parseNullFloat64 parses the cell of a sql.NullFloat64 field, blank cells are not valid.
+func parseNullFloat64(s string) (sql.NullFloat64, error) {
+	if s == "" {
+		return sql.NullFloat64{}, nil
+	}
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return sql.NullFloat64{}, err
+	}
+	return sql.NullFloat64{Float64: val, Valid: true}, nil
+}
+
+// parseNullBool parses the cell of a sql.NullBool field, blank cells are not valid.
+func parseNullBool(s string) (sql.NullBool, error) {
+	if s == "" {
+		return sql.NullBool{}, nil
+	}
+	val, err := parseBool(s)
+	if err != nil {
+		return sql.NullBool{}, err
+	}
+	return sql.NullBool{Bool: val, Valid: true}, nil
+}
+
+// parseNullTime parses the cell of a sql.NullTime field, blank cells are not valid.
+func parseNullTime(s string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	val, err := parseTime(s)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: val, Valid: true}, nil
+}
+
+// buildReadHeaderNameIndexCache creates a map linking the struct tag names to the column index of their csv header.
+// It validates that all headers exist in struct tags and checks for duplicate headers. Fields flagged optional, or all
+// fields when lenient header matching is enabled, may be missing from the header. Headers and struct tags are compared
+// after the configured HeaderNormalization, two of them normalizing to the same name returns ErrAmbiguousHeaderInCSV.
+// A header matching any alias of a field is bound under the field's first read name, a document containing more than
+// one alias of a field returns ErrMultipleAliasesInCSV. Fields named "#N" without a matching header are bound to the
+// zero based column N of the header.
+func buildReadHeaderNameIndexCache(headerLine []string, fields []structField, opts *ReaderOption) (map[string]int, error) {
+	norm := opts.headerNormalization
+	tagNames := make(map[string]string, len(fields))
+	for _, f := range fields {
+		for _, alias := range append([]string{f.name}, f.aliases...) {
+			key := norm.normalize(alias)
+			if _, ok := tagNames[key]; ok {
+				return nil, ErrAmbiguousHeaderInCSV
+			}
+			tagNames[key] = f.name
+		}
+	}
+
+	nameIndex := make(map[string]int, len(fields))
 
 	// Collect indexes for headers in struct tags.
 	for i, col := range headerLine {
-		if _, ok := nameIndex[col]; ok {
-			return nil, nil, ErrDuplicateHeaderInCSV
+		key := norm.normalize(col)
+		name, ok := tagNames[key]
+		if !ok {
+			continue
 		}
-		if _, ok := tFieldsIndexes[col]; ok {
-			nameIndex[col] = i
-			indexName[i] = col
+		if prev, dup := nameIndex[name]; dup {
+			switch {
+			case headerLine[prev] == col:
+				return nil, ErrDuplicateHeaderInCSV
+			case norm.normalize(headerLine[prev]) == key:
+				return nil, ErrAmbiguousHeaderInCSV
+			default:
+				return nil, ErrMultipleAliasesInCSV
+			}
 		}
+		nameIndex[name] = i
 	}
 
-	// check that all struct tags were in the header.
-	for k := range tFieldsIndexes {
-		if _, ok := nameIndex[k]; !ok {
-			return nil, nil, ErrStructTagNotInCSV
+	// check that all required struct tags were in the header.
+	for _, f := range fields {
+		if _, ok := nameIndex[f.name]; !ok && strings.HasPrefix(f.name, "#") && f.position >= 0 &&
+			f.position < len(headerLine) {
+			nameIndex[f.name] = f.position
+		}
+		if _, ok := nameIndex[f.name]; !ok && !f.optional && !opts.lenientHeaders {
+			return nil, ErrStructTagNotInCSV
 		}
 	}
 
-	return nameIndex, indexName, nil
+	return nameIndex, nil
 }
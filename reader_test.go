@@ -0,0 +1,745 @@
+package csvdoc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+type positionalRow struct {
+	A string `csvidx:"0"`
+	C string `csv:"c,,optional" csvidx:"3"`
+	B int    `csv:"#2"`
+}
+
+// generatedPositionalRow has the methods csvdoc-gen writes for positionalRow.
+type generatedPositionalRow positionalRow
+
+func (m *generatedPositionalRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.String(0, &m.A)
+	d.String(1, &m.C)
+	d.Int(2, &m.B)
+	return d.Err()
+}
+
+func (m *generatedPositionalRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.String(0, m.A)
+	e.String(1, m.C)
+	e.Int(2, m.B)
+	return e.Err()
+}
+
+func readAll[T any](t *testing.T, doc string, opts ...csvdoc.Option[csvdoc.ReaderOption]) ([]*T, error) {
+	t.Helper()
+	r, err := csvdoc.NewReader[T](strings.NewReader(doc), opts...)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*T
+	for row, rerr := range r.All() {
+		if rerr != nil {
+			return rows, rerr
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func TestReadHeaderlessShortRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr error
+		opts    []csvdoc.Option[csvdoc.ReaderOption]
+		wantCol int
+	}{
+		{name: "complete", doc: "a,x,1,c\n"},
+		{name: "optional missing", doc: "a,x,1\n"},
+		{name: "required missing", doc: "a,x,1,c\na,x\n", wantErr: csvdoc.ErrMissingColumn, wantCol: 2},
+		{
+			name: "lenient", doc: "a,x,1,c\na\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithLenientHeaders(true)},
+		},
+	}
+	for _, tt := range tests {
+		opts := append([]csvdoc.Option[csvdoc.ReaderOption]{
+			csvdoc.WithReadHeader(false), csvdoc.WithFieldsPerRecord(-1),
+		}, tt.opts...)
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readAll[positionalRow](t, tt.doc, opts...)
+			checkMissingColumn(t, err, tt.wantErr, tt.wantCol)
+		})
+		t.Run(tt.name+" generated", func(t *testing.T) {
+			_, err := readAll[generatedPositionalRow](t, tt.doc, opts...)
+			checkMissingColumn(t, err, tt.wantErr, tt.wantCol)
+		})
+	}
+}
+
+type headerPositionRow struct {
+	Name  string `csv:"name"`
+	Split string `csvidx:"1"`
+}
+
+func TestReadPositionWithHeader(t *testing.T) {
+	rows, err := readAll[headerPositionRow](t, "name,\"first, last\"\nx,y\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || *rows[0] != (headerPositionRow{Name: "x", Split: "y"}) {
+		t.Errorf("got %v, want the row x, y", rows)
+	}
+	_, err = readAll[headerPositionRow](t, "name\nx\n")
+	if !errors.Is(err, csvdoc.ErrStructTagNotInCSV) {
+		t.Errorf("header without column 1: got %v, want %v", err, csvdoc.ErrStructTagNotInCSV)
+	}
+}
+
+type timeRow struct {
+	Default time.Time `csv:"default"`
+	Tagged  time.Time `csv:"tagged" csvtime:"1/2/2006 3:04:05 PM"`
+}
+
+func TestReadTimeLayouts(t *testing.T) {
+	rows, err := readAll[timeRow](t, "default,tagged\n01/02/2006 13:30:00 PM,1/2/2006 1:30:00 PM\n"+
+		"1/2/2006 1:30:00 PM,01/02/2006 01:30:00 PM\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2006, 1, 2, 13, 30, 0, 0, time.UTC)
+	for i, row := range rows {
+		if !row.Default.Equal(want) || !row.Tagged.Equal(want) {
+			t.Errorf("row %d: got %v and %v, want %v", i, row.Default, row.Tagged, want)
+		}
+	}
+	if len(rows) != 2 {
+		t.Errorf("got %d rows, want 2", len(rows))
+	}
+}
+
+func checkMissingColumn(t *testing.T, err error, wantErr error, wantCol int) {
+	t.Helper()
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("got error %v, want %v", err, wantErr)
+	}
+	if wantErr == nil {
+		return
+	}
+	var perr *csvdoc.ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got %T, want *csvdoc.ParseError", err)
+	}
+	if perr.Index != wantCol || perr.Line != 2 {
+		t.Errorf("got line %d column %d, want line 2 column %d", perr.Line, perr.Index, wantCol)
+	}
+}
+
+var (
+	_ csvdoc.ContextReader[positionalRow] = (*csvdoc.StreamReader[positionalRow])(nil)
+	_ csvdoc.ContextReader[positionalRow] = (*csvdoc.ParallelReader[positionalRow])(nil)
+	_ csvdoc.ContextReader[positionalRow] = (*csvdoc.ChunkedReader[positionalRow])(nil)
+	_ csvdoc.ContextWriter[positionalRow] = (*csvdoc.StreamWriter[positionalRow])(nil)
+	_ csvdoc.CSVRowUnmarshaler            = (*generatedPositionalRow)(nil)
+	_ csvdoc.CSVRowMarshaler              = (*generatedPositionalRow)(nil)
+)
+
+// lineSource returns one line of its document per Read and calls cancel once after lines reads.
+type lineSource struct {
+	cancel context.CancelFunc
+	lines  []string
+	reads  int
+	after  int
+}
+
+func (s *lineSource) Read(p []byte) (int, error) {
+	if len(s.lines) == 0 {
+		return 0, io.EOF
+	}
+	s.reads++
+	if s.reads == s.after {
+		s.cancel()
+	}
+	n := copy(p, s.lines[0])
+	s.lines[0] = s.lines[0][n:]
+	if s.lines[0] == "" {
+		s.lines = s.lines[1:]
+	}
+	return n, nil
+}
+
+type skipRow struct {
+	A int `csv:"a"`
+}
+
+func TestReadContextCancelWhileSkipping(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src := &lineSource{cancel: cancel, after: 10, lines: []string{"a\n"}}
+	for i := range 1000 {
+		src.lines = append(src.lines, fmt.Sprintf("bad%d\n", i))
+	}
+	src.lines = append(src.lines, "1\n")
+
+	r, err := csvdoc.NewReader[skipRow](src, csvdoc.WithErrorPolicy(csvdoc.ErrorPolicySkip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := r.ReadContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, %v, want %v", row, err, context.Canceled)
+	}
+	if r.SkippedRows() >= 1000 {
+		t.Errorf("skipped %d rows after the context was cancelled", r.SkippedRows())
+	}
+}
+
+type lineRow struct {
+	A string `csv:"a"`
+	B int    `csv:"b"`
+}
+
+// contextReaders creates each reader of the package for doc.
+func contextReaders[T any](t *testing.T, doc string, opts ...csvdoc.Option[csvdoc.ReaderOption],
+) map[string]csvdoc.ContextReader[T] {
+	t.Helper()
+	sr, err := csvdoc.NewReader[T](strings.NewReader(doc), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := csvdoc.NewParallelReader[T](strings.NewReader(doc), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr, err := csvdoc.NewChunkedReader[T](strings.NewReader(doc), int64(len(doc)),
+		append(opts, csvdoc.WithChunkSize(4))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]csvdoc.ContextReader[T]{"stream": sr, "parallel": pr, "chunked": cr}
+}
+
+func TestRowLineSameAcrossReaders(t *testing.T) {
+	doc := "a,b\n\"x\ny\",1\n\"p\nq\",bad\n"
+	for name, r := range contextReaders[lineRow](t, doc) {
+		t.Run(name, func(t *testing.T) {
+			_, err := r.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = r.Read()
+			var perr *csvdoc.ParseError
+			if !errors.As(err, &perr) || perr.Line != 5 || perr.Index != 1 {
+				t.Errorf("got %v, want a ParseError on line 5 column 1", err)
+			}
+		})
+	}
+
+	for name, r := range contextReaders[lineRow](t, doc) {
+		t.Run(name+" cancelled", func(t *testing.T) {
+			_, err := r.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err = r.ReadContext(ctx)
+			if err == nil || err.Error() != "read cancelled after line 2: context canceled" {
+				t.Errorf("got %v, want the start line 2 of the last row", err)
+			}
+		})
+	}
+}
+
+// collect reads the rows of r until io.EOF or the first error.
+func collect[T any](r csvdoc.Reader[T]) ([]T, error) {
+	var rows []T
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, *row)
+	}
+}
+
+// errorString returns the message of err, empty for nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// checkGoroutines fails the test when goroutines started during it are still running once it ends.
+func checkGoroutines(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := runtime.NumGoroutine(); n > before {
+			t.Errorf("%d goroutines still running, %d before the test", n, before)
+		}
+	})
+}
+
+func TestReadErrorPolicy(t *testing.T) {
+	doc := "id,name,note\n1,a,x\nbad,b,y\n3,c\"q,z\n4,d,w\n"
+	convErr := `line 3, column 0 "id" (field ID int): cannot convert "bad": ` +
+		`strconv.ParseInt: parsing "bad": invalid syntax`
+	quoteErr := `parse error on line 4, column 4: bare " in non-quoted-field`
+	tests := []struct {
+		name     string
+		wantErr  string
+		wantRows []numberedRow
+		wantErrs []string
+		skipped  int
+		policy   csvdoc.ErrorPolicy
+	}{
+		{
+			name: "fail fast", policy: csvdoc.ErrorPolicyFailFast, wantErr: convErr,
+			wantRows: []numberedRow{{ID: 1, Name: "a", Note: "x"}},
+		},
+		{
+			name: "skip", policy: csvdoc.ErrorPolicySkip, skipped: 2,
+			wantRows: []numberedRow{{ID: 1, Name: "a", Note: "x"}, {ID: 4, Name: "d", Note: "w"}},
+		},
+		{
+			name: "collect", policy: csvdoc.ErrorPolicyCollect, skipped: 2,
+			wantRows: []numberedRow{{ID: 1, Name: "a", Note: "x"}, {ID: 4, Name: "d", Note: "w"}},
+			wantErrs: []string{"3 " + convErr, "4 " + quoteErr},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr, err := csvdoc.NewReader[numberedRow](strings.NewReader(doc), csvdoc.WithErrorPolicy(tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows, err := collect[numberedRow](sr)
+			if errorString(err) != tt.wantErr {
+				t.Errorf("got error %v, want %s", err, tt.wantErr)
+			}
+			if !slices.Equal(rows, tt.wantRows) {
+				t.Errorf("got rows %v, want %v", rows, tt.wantRows)
+			}
+			if sr.SkippedRows() != tt.skipped {
+				t.Errorf("got %d skipped rows, want %d", sr.SkippedRows(), tt.skipped)
+			}
+			var errs []string
+			for _, perr := range sr.Errors() {
+				errs = append(errs, fmt.Sprintf("%d %v", perr.Line, perr))
+			}
+			if !slices.Equal(errs, tt.wantErrs) {
+				t.Errorf("got errors %q, want %q", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestReadRejectWriter(t *testing.T) {
+	doc := "id,name,note\n1,a,x\nbad,b,y\n4,d,\"w,v\"\nx5,e,z\n"
+	var rejected bytes.Buffer
+	sr, err := csvdoc.NewReader[numberedRow](strings.NewReader(doc), csvdoc.WithErrorPolicy(csvdoc.ErrorPolicySkip),
+		csvdoc.WithRejectWriter(&rejected))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := collect[numberedRow](sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("got %d rows, want 2", len(rows))
+	}
+	want := "id,name,note,error\n" +
+		`bad,b,y,"line 3, column 0 ""id"" (field ID int): cannot convert ""bad"": strconv.ParseInt: parsing ""bad"": ` +
+		"invalid syntax\"\n" +
+		`x5,e,z,"line 5, column 0 ""id"" (field ID int): cannot convert ""x5"": strconv.ParseInt: parsing ""x5"": ` +
+		"invalid syntax\"\n"
+	if rejected.String() != want {
+		t.Errorf("got rejected rows %q, want %q", rejected.String(), want)
+	}
+}
+
+func TestReadMaxErrors(t *testing.T) {
+	doc := "id,name,note\n1,a,x\nbad,b,y\n3,c,z\nx4,d,w\n5,e,v\n"
+	sr, err := csvdoc.NewReader[numberedRow](strings.NewReader(doc), csvdoc.WithErrorPolicy(csvdoc.ErrorPolicyCollect),
+		csvdoc.WithMaxErrors(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := collect[numberedRow](sr)
+	if !errors.Is(err, csvdoc.ErrTooManyRowErrors) {
+		t.Fatalf("got %v, want %v", err, csvdoc.ErrTooManyRowErrors)
+	}
+	var perr *csvdoc.ParseError
+	if !errors.As(err, &perr) || perr.Line != 5 {
+		t.Errorf("got %v, want the ParseError of line 5", err)
+	}
+	if len(rows) != 2 || len(sr.Errors()) != 1 || sr.SkippedRows() != 2 {
+		t.Errorf("got %d rows, %d errors and %d skipped rows, want 2, 1 and 2", len(rows), len(sr.Errors()),
+			sr.SkippedRows())
+	}
+}
+
+func TestReadParseErrorFields(t *testing.T) {
+	_, err := readAll[numberedRow](t, "name,id,note\na,1,x\n\"b\nc\",bad,y\n")
+	var perr *csvdoc.ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got %v, want a *csvdoc.ParseError", err)
+	}
+	want := csvdoc.ParseError{
+		Header: "id", Field: "ID", Type: reflect.TypeFor[int](), Value: "bad", Line: 4, Index: 1, Err: perr.Err,
+	}
+	if *perr != want {
+		t.Errorf("got %+v, want %+v", *perr, want)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("got %v, want it to wrap %v", err, strconv.ErrSyntax)
+	}
+}
+
+type aliasRow struct {
+	DOB  string `csv:"birthDate|birth_date|DOB"`
+	Name string `csv:"name"`
+}
+
+func TestReadAliases(t *testing.T) {
+	tests := []struct {
+		doc     string
+		matched string
+	}{
+		{doc: "name,birthDate\nx,2000\n", matched: "birthDate"},
+		{doc: "birth_date,name\n2000,x\n", matched: "birth_date"},
+		{doc: "name,DOB\nx,2000\n", matched: "DOB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.matched, func(t *testing.T) {
+			sr, err := csvdoc.NewReader[aliasRow](strings.NewReader(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			row, err := sr.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *row != (aliasRow{DOB: "2000", Name: "x"}) {
+				t.Errorf("got %+v, want DOB 2000 and name x", *row)
+			}
+			matched, ok := sr.MatchedHeader("birthDate")
+			if !ok || matched != tt.matched {
+				t.Errorf("got matched header %q, %v, want %q", matched, ok, tt.matched)
+			}
+			if _, ok = sr.MatchedHeader(tt.matched + "x"); ok {
+				t.Errorf("MatchedHeader reports a header for an unknown name")
+			}
+		})
+	}
+
+	_, err := csvdoc.NewReader[aliasRow](strings.NewReader("birthDate,name,DOB\n2000,x,2001\n"))
+	if !errors.Is(err, csvdoc.ErrMultipleAliasesInCSV) {
+		t.Errorf("two aliases: got %v, want %v", err, csvdoc.ErrMultipleAliasesInCSV)
+	}
+}
+
+type normalizedRow struct {
+	FirstName string `csv:"First Name"`
+	ID        int    `csv:"id"`
+}
+
+func TestReadHeaderNormalization(t *testing.T) {
+	tests := []struct {
+		wantErr error
+		name    string
+		header  string
+		norm    csvdoc.HeaderNormalization
+	}{
+		{name: "none", header: "First Name,id", norm: csvdoc.NormalizeNone},
+		{name: "none differs", header: "first name,id", norm: csvdoc.NormalizeNone, wantErr: csvdoc.ErrStructTagNotInCSV},
+		{name: "case", header: "FIRST NAME,ID", norm: csvdoc.NormalizeCase},
+		{
+			name: "case keeps space", header: " first name,id", norm: csvdoc.NormalizeCase,
+			wantErr: csvdoc.ErrStructTagNotInCSV,
+		},
+		{name: "space", header: "  First Name ,id\t", norm: csvdoc.NormalizeSpace},
+		{
+			name: "space keeps case", header: " first name,id", norm: csvdoc.NormalizeSpace,
+			wantErr: csvdoc.ErrStructTagNotInCSV,
+		},
+		{name: "bom", header: "\uFEFFFirst Name,id", norm: csvdoc.NormalizeBOM},
+		{
+			name: "bom missing", header: "\uFEFFFirst Name,id", norm: csvdoc.NormalizeCase,
+			wantErr: csvdoc.ErrStructTagNotInCSV,
+		},
+		{name: "separators", header: "First_Name,id", norm: csvdoc.NormalizeSeparators},
+		{name: "case and space", header: " first name ,ID", norm: csvdoc.NormalizeCase | csvdoc.NormalizeSpace},
+		{
+			name: "bom, case and space", header: "\uFEFF FIRST name,id",
+			norm: csvdoc.NormalizeBOM | csvdoc.NormalizeCase | csvdoc.NormalizeSpace,
+		},
+		{name: "all", header: "\uFEFFfirst-NAME ,ID", norm: csvdoc.NormalizeAll},
+		{
+			name: "ambiguous", header: "first name,First Name,id", norm: csvdoc.NormalizeCase,
+			wantErr: csvdoc.ErrAmbiguousHeaderInCSV,
+		},
+		{
+			name: "ambiguous separators", header: "First Name,First-Name,id", norm: csvdoc.NormalizeSeparators,
+			wantErr: csvdoc.ErrAmbiguousHeaderInCSV,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readAll[normalizedRow](t, tt.header+"\nx,1\n", csvdoc.WithHeaderNormalization(tt.norm))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (len(rows) != 1 || *rows[0] != (normalizedRow{FirstName: "x", ID: 1})) {
+				t.Errorf("got %v, want the row x, 1", rows)
+			}
+		})
+	}
+}
+
+// closeTracker is an io.Reader and io.Writer counting the calls to Close.
+type closeTracker struct {
+	io.Reader
+	io.Writer
+	closed int
+}
+
+func (c *closeTracker) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestReaderCloseOwnership(t *testing.T) {
+	doc := "id,name,note\n1,a,x\n"
+	for _, owned := range []bool{false, true} {
+		t.Run(fmt.Sprintf("owned %v", owned), func(t *testing.T) {
+			want := 0
+			if owned {
+				want = 1
+			}
+
+			src := &closeTracker{Reader: strings.NewReader(doc)}
+			sr, err := csvdoc.NewReader[numberedRow](src, csvdoc.WithCloseSource(owned))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = collect[numberedRow](sr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.closed != want {
+				t.Errorf("at the end of the document: got %d closes, want %d", src.closed, want)
+			}
+			err = sr.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.closed != want {
+				t.Errorf("after Close: got %d closes, want %d", src.closed, want)
+			}
+
+			src = &closeTracker{Reader: strings.NewReader(doc)}
+			sr, err = csvdoc.NewReader[numberedRow](src, csvdoc.WithCloseSource(owned))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sr.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.closed != want {
+				t.Errorf("Close before reading: got %d closes, want %d", src.closed, want)
+			}
+		})
+	}
+}
+
+func TestReaderReset(t *testing.T) {
+	doc := "id,name,note\n1,a,x\n2,b,y\n"
+	src := strings.NewReader("skipped prefix\n" + doc)
+	_, err := src.Seek(int64(len("skipped prefix\n")), io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr, err := csvdoc.NewReader[numberedRow](src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := sr.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sr.Reset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := collect[numberedRow](sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []numberedRow{*first, {ID: 2, Name: "b", Note: "y"}}
+	if !slices.Equal(rows, want) {
+		t.Errorf("after Reset: got %v, want %v", rows, want)
+	}
+
+	headerless, err := csvdoc.NewReader[positionalRow](strings.NewReader("a,x,1,c\nb,y,2,d\n"),
+		csvdoc.WithReadHeader(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = collect[positionalRow](headerless)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = headerless.Reset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := headerless.Read()
+	if err != nil || *row != (positionalRow{A: "a", B: 1, C: "c"}) {
+		t.Errorf("headerless after Reset: got %v, %v, want the first record", row, err)
+	}
+
+	unseekable, err := csvdoc.NewReader[numberedRow](struct{ io.Reader }{strings.NewReader(doc)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = unseekable.Reset(); !errors.Is(err, csvdoc.ErrResetNotSupported) {
+		t.Errorf("unseekable source: got %v, want %v", err, csvdoc.ErrResetNotSupported)
+	}
+}
+
+func TestReaderOptionsReachCSVReader(t *testing.T) {
+	tests := []struct {
+		wantErr error
+		name    string
+		doc     string
+		opts    []csvdoc.Option[csvdoc.ReaderOption]
+		want    []lineRow
+	}{
+		{
+			name: "comma", doc: "a;b\nx,y;1\n", opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithComma(';')},
+			want: []lineRow{{A: "x,y", B: 1}},
+		},
+		{
+			name: "comment", doc: "a,b\n# a note\nx,1\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithComment('#')}, want: []lineRow{{A: "x", B: 1}},
+		},
+		{name: "no comment", doc: "a,b\n# a note,2\nx,1\n", want: []lineRow{{A: "# a note", B: 2}, {A: "x", B: 1}}},
+		{
+			name: "fields per record", doc: "a,b\nx,1\n", wantErr: csv.ErrFieldCount,
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithFieldsPerRecord(3)},
+		},
+		{
+			name: "any fields per record", doc: "a,b\nx,1,extra\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithFieldsPerRecord(-1)}, want: []lineRow{{A: "x", B: 1}},
+		},
+		{name: "fields of the header", doc: "a,b\nx,1,extra\n", wantErr: csv.ErrFieldCount},
+		{
+			name: "lazy quotes", doc: "a,b\nx\"y,1\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithLazyQuotes(true)}, want: []lineRow{{A: "x\"y", B: 1}},
+		},
+		{name: "strict quotes", doc: "a,b\nx\"y,1\n", wantErr: csv.ErrBareQuote},
+		{
+			name: "trim leading space", doc: "a, b\n  x, 1\n",
+			opts: []csvdoc.Option[csvdoc.ReaderOption]{csvdoc.WithTrimLeadingSpace(true)}, want: []lineRow{{A: "x", B: 1}},
+		},
+		{name: "keep leading space", doc: "a,b\n  x,1\n", want: []lineRow{{A: "  x", B: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readAll[lineRow](t, tt.doc, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			var got []lineRow
+			for _, row := range rows {
+				got = append(got, *row)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderReuseRecord(t *testing.T) {
+	doc := strings.Repeat("x,1\n", 200)
+	allocs := func(reuse bool) float64 {
+		sr, err := csvdoc.NewReader[lineRow](strings.NewReader("a,b\n"+doc), csvdoc.WithReuseRecord(reuse))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var row lineRow
+		return testing.AllocsPerRun(100, func() {
+			err = sr.ReadInto(&row)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	reused, fresh := allocs(true), allocs(false)
+	if reused >= fresh {
+		t.Errorf("got %v allocations per row reusing records and %v without, want fewer when reusing", reused, fresh)
+	}
+}
+
+func TestAllClosesOwnedSource(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "doc.csv")
+	err := os.WriteFile(fp, []byte(numberedDocument(2000, nil)), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr, err := csvdoc.NewFileReader[numberedRow](fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rerr := range fr.All() {
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		break
+	}
+	// the csv.Reader buffers the start of the file, reading on reaches the closed file.
+	for err == nil {
+		_, err = fr.Read()
+	}
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Read after breaking out of All: got %v, want %v", err, os.ErrClosed)
+	}
+
+	src := &closeTracker{Reader: strings.NewReader(numberedDocument(10, nil))}
+	sr, err := csvdoc.NewReader[numberedRow](src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range sr.All() {
+		break
+	}
+	if src.closed != 0 {
+		t.Errorf("breaking out of All closed a source the reader does not own")
+	}
+}
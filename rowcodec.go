@@ -0,0 +1,657 @@
+package csvdoc
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// CSVRowUnmarshaler is implemented by struct types decoding a csv row without reflection, usually generated by
+// cmd/csvdoc-gen. Readers detect it on *T and prefer it over reflection. The method calls one RowDecoder method per
+// csv tagged field and returns RowDecoder.Err.
+type CSVRowUnmarshaler interface {
+	UnmarshalCSVRow(d *RowDecoder) error
+}
+
+// CSVRowMarshaler is implemented by struct types encoding a csv row without reflection, usually generated by
+// cmd/csvdoc-gen. Writers detect it on *T and prefer it over reflection. The method calls one RowEncoder method per
+// csv tagged field and returns RowEncoder.Err.
+type CSVRowMarshaler interface {
+	MarshalCSVRow(e *RowEncoder) error
+}
+
+// probedField is a field visited by a generated method while its plan is checked. typ is nil for fields handled by
+// the reflection fallback.
+type probedField struct {
+	typ   reflect.Type
+	index int
+}
+
+// RowDecoder converts the cells of a csv record into struct fields identified by their field index. Fields without a
+// bound column are left untouched and columns with a custom Conversion use it through reflection. The error of the
+// leftmost failing column is kept so errors match the reflection based decoding.
+type RowDecoder struct {
+	err       *ParseError
+	elem      reflect.Value
+	columns   []readColumn
+	fieldCols []int
+	record    []string
+	probed    []probedField
+	generated bool
+	probing   bool
+}
+
+// newRowDecoder creates a RowDecoder for the bound columns. generated enables the CSVRowUnmarshaler method of the type.
+func newRowDecoder(columns []readColumn, generated bool) *RowDecoder {
+	d := &RowDecoder{columns: columns, generated: generated}
+	for i := range columns {
+		idx := columns[i].field.index
+		for len(d.fieldCols) <= idx {
+			d.fieldCols = append(d.fieldCols, -1)
+		}
+		d.fieldCols[idx] = i
+	}
+	return d
+}
+
+// decode converts record into dst, a pointer to the struct. Conversion failures are returned as *ParseError, the
+// caller is responsible for setting its Line.
+func (d *RowDecoder) decode(record []string, dst any) error {
+	d.record, d.err = record, nil
+	d.elem = reflect.ValueOf(dst).Elem()
+	if u, ok := dst.(CSVRowUnmarshaler); ok && d.generated {
+		err := u.UnmarshalCSVRow(d)
+		if d.err != nil {
+			return d.err
+		}
+		return err
+	}
+
+	for i := range d.columns {
+		c := &d.columns[i]
+		if c.col >= len(record) {
+			if c.required {
+				d.fail(c, ErrMissingColumn)
+				return d.err
+			}
+			continue
+		}
+		d.convert(c)
+		if d.err != nil {
+			return d.err
+		}
+	}
+	return nil
+}
+
+// Err returns the error of the leftmost column that failed to convert, as *ParseError.
+func (d *RowDecoder) Err() error {
+	if d.err != nil {
+		return d.err
+	}
+	return nil
+}
+
+// column returns the column bound to struct field index field. It returns nil when there is nothing left for the
+// caller to convert: the field is not bound, the record is too short, failing required columns with ErrMissingColumn,
+// a column left of it already failed or the column has a custom Conversion, which is applied here.
+func (d *RowDecoder) column(field int) *readColumn {
+	if field >= len(d.fieldCols) || d.fieldCols[field] < 0 {
+		return nil
+	}
+	c := &d.columns[d.fieldCols[field]]
+	if d.err != nil && d.err.Index < c.col {
+		return nil
+	}
+	if c.col >= len(d.record) {
+		if c.required {
+			d.fail(c, ErrMissingColumn)
+		}
+		return nil
+	}
+	if c.custom {
+		d.convert(c)
+		return nil
+	}
+	return c
+}
+
+// convert applies the Conversion of c to its field through reflection.
+func (d *RowDecoder) convert(c *readColumn) {
+	if c.conv == nil {
+		d.fail(c, ErrConverterNotFoundForType)
+		return
+	}
+	f := d.elem.Field(c.field.index)
+	s := d.record[c.col]
+	if !c.custom {
+		s = d.cell(c)
+	}
+	d.fail(c, c.conv(s, &f))
+}
+
+// cell returns the cell of column c, blank when it matches one of the null tokens of the column.
+func (d *RowDecoder) cell(c *readColumn) string {
+	s := d.record[c.col]
+	for _, token := range c.nulls {
+		if s == token {
+			return ""
+		}
+	}
+	return s
+}
+
+// fail records err for column c unless a column left of it already failed.
+func (d *RowDecoder) fail(c *readColumn, err error) {
+	if err == nil || (d.err != nil && d.err.Index < c.col) {
+		return
+	}
+	value := ""
+	if c.col < len(d.record) {
+		value = d.record[c.col]
+	}
+	d.err = &ParseError{
+		Index:  c.col,
+		Header: c.field.name,
+		Field:  c.field.fieldName,
+		Type:   c.field.typ,
+		Value:  value,
+		Err:    err,
+	}
+}
+
+// decodeField parses the cell bound to field with parse and stores the result in dst.
+func decodeField[V any](d *RowDecoder, field int, dst *V, parse func(string) (V, error)) {
+	if d.probing {
+		d.probed = append(d.probed, probedField{index: field, typ: reflect.TypeFor[V]()})
+		return
+	}
+	c := d.column(field)
+	if c == nil {
+		return
+	}
+	val, err := parse(d.cell(c))
+	if err != nil {
+		d.fail(c, err)
+		return
+	}
+	*dst = val
+}
+
+// decodeTimeField converts the cell bound to field into dst with parse and the time format of the field.
+func decodeTimeField[V time.Time | sql.NullTime](d *RowDecoder, field int, dst *V,
+	parse func(*timeFormat, string) (V, error),
+) {
+	if d.probing {
+		d.probed = append(d.probed, probedField{index: field, typ: reflect.TypeFor[V]()})
+		return
+	}
+	c := d.column(field)
+	if c == nil {
+		return
+	}
+	val, err := parse(c.field.time, d.cell(c))
+	if err != nil {
+		d.fail(c, err)
+		return
+	}
+	*dst = val
+}
+
+// parseSigned parses the cell of an int* field of type V.
+func parseSigned[V int | int16 | int32 | int64](s string) (V, error) {
+	val, err := parseInt(s)
+	if err != nil {
+		return 0, err
+	}
+	if int64(V(val)) != val {
+		return 0, errors.New("overflow convert")
+	}
+	return V(val), nil
+}
+
+// parseUnsigned parses the cell of an uint* field of type V.
+func parseUnsigned[V uint | uint16 | uint32 | uint64](s string) (V, error) {
+	val, err := parseUint(s)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(V(val)) != val {
+		return 0, ErrTypeOverflow
+	}
+	return V(val), nil
+}
+
+// parseFloat32 parses the cell of a float32 field. Like reflect.Value.OverflowFloat infinity does not overflow.
+func parseFloat32(s string) (float32, error) {
+	val, err := parseFloat(s)
+	if err != nil {
+		return 0, err
+	}
+	if abs := math.Abs(val); abs > math.MaxFloat32 && abs <= math.MaxFloat64 {
+		return 0, ErrTypeOverflow
+	}
+	return float32(val), nil
+}
+
+// parseString returns the cell of a string field.
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+// Field converts the cell bound to field through reflection, for field types without a typed method.
+func (d *RowDecoder) Field(field int) {
+	if d.probing {
+		d.probed = append(d.probed, probedField{index: field})
+		return
+	}
+	c := d.column(field)
+	if c != nil {
+		d.convert(c)
+	}
+}
+
+// String decodes the cell bound to field into dst.
+func (d *RowDecoder) String(field int, dst *string) {
+	decodeField(d, field, dst, parseString)
+}
+
+// Int decodes the cell bound to field into dst.
+func (d *RowDecoder) Int(field int, dst *int) {
+	decodeField(d, field, dst, parseSigned[int])
+}
+
+// Int16 decodes the cell bound to field into dst.
+func (d *RowDecoder) Int16(field int, dst *int16) {
+	decodeField(d, field, dst, parseSigned[int16])
+}
+
+// Int32 decodes the cell bound to field into dst.
+func (d *RowDecoder) Int32(field int, dst *int32) {
+	decodeField(d, field, dst, parseSigned[int32])
+}
+
+// Int64 decodes the cell bound to field into dst.
+func (d *RowDecoder) Int64(field int, dst *int64) {
+	decodeField(d, field, dst, parseSigned[int64])
+}
+
+// Uint decodes the cell bound to field into dst.
+func (d *RowDecoder) Uint(field int, dst *uint) {
+	decodeField(d, field, dst, parseUnsigned[uint])
+}
+
+// Uint16 decodes the cell bound to field into dst.
+func (d *RowDecoder) Uint16(field int, dst *uint16) {
+	decodeField(d, field, dst, parseUnsigned[uint16])
+}
+
+// Uint32 decodes the cell bound to field into dst.
+func (d *RowDecoder) Uint32(field int, dst *uint32) {
+	decodeField(d, field, dst, parseUnsigned[uint32])
+}
+
+// Uint64 decodes the cell bound to field into dst.
+func (d *RowDecoder) Uint64(field int, dst *uint64) {
+	decodeField(d, field, dst, parseUnsigned[uint64])
+}
+
+// Float32 decodes the cell bound to field into dst.
+func (d *RowDecoder) Float32(field int, dst *float32) {
+	decodeField(d, field, dst, parseFloat32)
+}
+
+// Float64 decodes the cell bound to field into dst.
+func (d *RowDecoder) Float64(field int, dst *float64) {
+	decodeField(d, field, dst, parseFloat)
+}
+
+// Bool decodes the cell bound to field into dst.
+func (d *RowDecoder) Bool(field int, dst *bool) {
+	decodeField(d, field, dst, parseBool)
+}
+
+// Time decodes the cell bound to field into dst.
+func (d *RowDecoder) Time(field int, dst *time.Time) {
+	decodeTimeField(d, field, dst, (*timeFormat).parse)
+}
+
+// NullString decodes the cell bound to field into dst.
+func (d *RowDecoder) NullString(field int, dst *sql.NullString) {
+	decodeField(d, field, dst, parseNullString)
+}
+
+// NullInt64 decodes the cell bound to field into dst.
+func (d *RowDecoder) NullInt64(field int, dst *sql.NullInt64) {
+	decodeField(d, field, dst, parseNullInt64)
+}
+
+// NullInt32 decodes the cell bound to field into dst.
+func (d *RowDecoder) NullInt32(field int, dst *sql.NullInt32) {
+	decodeField(d, field, dst, parseNullInt32)
+}
+
+// NullInt16 decodes the cell bound to field into dst.
+func (d *RowDecoder) NullInt16(field int, dst *sql.NullInt16) {
+	decodeField(d, field, dst, parseNullInt16)
+}
+
+// NullFloat64 decodes the cell bound to field into dst.
+func (d *RowDecoder) NullFloat64(field int, dst *sql.NullFloat64) {
+	decodeField(d, field, dst, parseNullFloat64)
+}
+
+// NullBool decodes the cell bound to field into dst.
+func (d *RowDecoder) NullBool(field int, dst *sql.NullBool) {
+	decodeField(d, field, dst, parseNullBool)
+}
+
+// NullTime decodes the cell bound to field into dst.
+func (d *RowDecoder) NullTime(field int, dst *sql.NullTime) {
+	decodeTimeField(d, field, dst, (*timeFormat).parseNull)
+}
+
+// RowEncoder converts struct fields identified by their field index into the cells of a csv row. Fields without a
+// bound column are skipped and columns with a custom ToStringConversion use it through reflection. The error of the
+// leftmost failing column is kept so errors match the reflection based encoding.
+type RowEncoder struct {
+	err       error
+	elem      reflect.Value
+	columns   []writeColumn
+	fieldCols []int
+	row       []string
+	probed    []probedField
+	errCol    int
+	generated bool
+	probing   bool
+}
+
+// newRowEncoder creates a RowEncoder for the bound columns writing into row. generated enables the CSVRowMarshaler
+// method of the type.
+func newRowEncoder(columns []writeColumn, row []string, generated bool) *RowEncoder {
+	e := &RowEncoder{columns: columns, row: row, generated: generated}
+	for i := range columns {
+		idx := columns[i].field.index
+		for len(e.fieldCols) <= idx {
+			e.fieldCols = append(e.fieldCols, -1)
+		}
+		e.fieldCols[idx] = i
+	}
+	return e
+}
+
+// encode converts src, a pointer to the struct, into the row of the encoder.
+func (e *RowEncoder) encode(src any) error {
+	e.err = nil
+	e.elem = reflect.ValueOf(src).Elem()
+	if m, ok := src.(CSVRowMarshaler); ok && e.generated {
+		err := m.MarshalCSVRow(e)
+		if e.err != nil {
+			return e.err
+		}
+		return err
+	}
+
+	for i := range e.columns {
+		e.convert(&e.columns[i])
+		if e.err != nil {
+			return e.err
+		}
+	}
+	return nil
+}
+
+// Err returns the error of the leftmost column that failed to convert.
+func (e *RowEncoder) Err() error {
+	return e.err
+}
+
+// column returns the column bound to struct field index field. It returns nil when there is nothing left for the
+// caller to convert: the field is not bound, a column left of it already failed or the column has a custom
+// ToStringConversion, which is applied here.
+func (e *RowEncoder) column(field int) *writeColumn {
+	if field >= len(e.fieldCols) || e.fieldCols[field] < 0 {
+		return nil
+	}
+	c := &e.columns[e.fieldCols[field]]
+	if e.err != nil && e.errCol < c.col {
+		return nil
+	}
+	if c.custom {
+		e.convert(c)
+		return nil
+	}
+	return c
+}
+
+// convert applies the ToStringConversion of c to its field through reflection.
+func (e *RowEncoder) convert(c *writeColumn) {
+	if c.toString == nil {
+		e.fail(c, ErrConverterNotFoundForType)
+		return
+	}
+	f := e.elem.Field(c.field.index)
+	s, err := c.toString(&f)
+	if err != nil {
+		e.fail(c, err)
+		return
+	}
+	if s == "" && c.null != "" && !c.custom && isNull(f) {
+		s = c.null
+	}
+	e.row[c.col] = s
+}
+
+// fail records err for column c unless a column left of it already failed.
+func (e *RowEncoder) fail(c *writeColumn, err error) {
+	if e.err != nil && e.errCol < c.col {
+		return
+	}
+	e.err, e.errCol = err, c.col
+}
+
+// encodeField formats v with format into the cell bound to field.
+func encodeField[V any](e *RowEncoder, field int, v V, format func(V) string) {
+	if e.probing {
+		e.probed = append(e.probed, probedField{index: field, typ: reflect.TypeFor[V]()})
+		return
+	}
+	c := e.column(field)
+	if c != nil {
+		e.row[c.col] = format(v)
+	}
+}
+
+// encodeNullField formats v with format into the cell bound to field, invalid values are written as the null token of
+// the column.
+func encodeNullField[V any](e *RowEncoder, field int, v V, valid bool, format func(V) string) {
+	if e.probing {
+		e.probed = append(e.probed, probedField{index: field, typ: reflect.TypeFor[V]()})
+		return
+	}
+	c := e.column(field)
+	if c == nil {
+		return
+	}
+	if valid {
+		e.row[c.col] = format(v)
+		return
+	}
+	e.row[c.col] = c.null
+}
+
+// encodeTimeField formats v with format and the time format of the field into the cell bound to field, invalid values
+// are written as the null token of the column.
+func encodeTimeField[V time.Time | sql.NullTime](e *RowEncoder, field int, v V, valid bool,
+	format func(*timeFormat, V) string,
+) {
+	if e.probing {
+		e.probed = append(e.probed, probedField{index: field, typ: reflect.TypeFor[V]()})
+		return
+	}
+	c := e.column(field)
+	if c == nil {
+		return
+	}
+	if valid {
+		e.row[c.col] = format(c.field.time, v)
+		return
+	}
+	e.row[c.col] = c.null
+}
+
+// formatSigned formats the value of an int* field.
+func formatSigned[V int | int16 | int32 | int64](v V) string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+// formatUnsigned formats the value of an uint* field.
+func formatUnsigned[V uint | uint16 | uint32 | uint64](v V) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// formatFloat32 formats the value of a float32 field.
+func formatFloat32(v float32) string {
+	return formatFloat(float64(v))
+}
+
+// formatString returns the value of a string field.
+func formatString(v string) string {
+	return v
+}
+
+// Field converts the field through reflection, for field types without a typed method.
+func (e *RowEncoder) Field(field int) {
+	if e.probing {
+		e.probed = append(e.probed, probedField{index: field})
+		return
+	}
+	c := e.column(field)
+	if c != nil {
+		e.convert(c)
+	}
+}
+
+// String encodes v into the cell bound to field.
+func (e *RowEncoder) String(field int, v string) {
+	encodeField(e, field, v, formatString)
+}
+
+// Int encodes v into the cell bound to field.
+func (e *RowEncoder) Int(field int, v int) {
+	encodeField(e, field, v, formatSigned[int])
+}
+
+// Int16 encodes v into the cell bound to field.
+func (e *RowEncoder) Int16(field int, v int16) {
+	encodeField(e, field, v, formatSigned[int16])
+}
+
+// Int32 encodes v into the cell bound to field.
+func (e *RowEncoder) Int32(field int, v int32) {
+	encodeField(e, field, v, formatSigned[int32])
+}
+
+// Int64 encodes v into the cell bound to field.
+func (e *RowEncoder) Int64(field int, v int64) {
+	encodeField(e, field, v, formatSigned[int64])
+}
+
+// Uint encodes v into the cell bound to field.
+func (e *RowEncoder) Uint(field int, v uint) {
+	encodeField(e, field, v, formatUnsigned[uint])
+}
+
+// Uint16 encodes v into the cell bound to field.
+func (e *RowEncoder) Uint16(field int, v uint16) {
+	encodeField(e, field, v, formatUnsigned[uint16])
+}
+
+// Uint32 encodes v into the cell bound to field.
+func (e *RowEncoder) Uint32(field int, v uint32) {
+	encodeField(e, field, v, formatUnsigned[uint32])
+}
+
+// Uint64 encodes v into the cell bound to field.
+func (e *RowEncoder) Uint64(field int, v uint64) {
+	encodeField(e, field, v, formatUnsigned[uint64])
+}
+
+// Float32 encodes v into the cell bound to field.
+func (e *RowEncoder) Float32(field int, v float32) {
+	encodeField(e, field, v, formatFloat32)
+}
+
+// Float64 encodes v into the cell bound to field.
+func (e *RowEncoder) Float64(field int, v float64) {
+	encodeField(e, field, v, formatFloat)
+}
+
+// Bool encodes v into the cell bound to field.
+func (e *RowEncoder) Bool(field int, v bool) {
+	encodeField(e, field, v, strconv.FormatBool)
+}
+
+// Time encodes v into the cell bound to field.
+func (e *RowEncoder) Time(field int, v time.Time) {
+	encodeTimeField(e, field, v, true, (*timeFormat).format)
+}
+
+// NullString encodes v into the cell bound to field.
+func (e *RowEncoder) NullString(field int, v sql.NullString) {
+	encodeNullField(e, field, v, v.Valid, formatNullString)
+}
+
+// NullInt64 encodes v into the cell bound to field.
+func (e *RowEncoder) NullInt64(field int, v sql.NullInt64) {
+	encodeNullField(e, field, v, v.Valid, formatNullInt64)
+}
+
+// NullInt32 encodes v into the cell bound to field.
+func (e *RowEncoder) NullInt32(field int, v sql.NullInt32) {
+	encodeNullField(e, field, v, v.Valid, formatNullInt32)
+}
+
+// NullInt16 encodes v into the cell bound to field.
+func (e *RowEncoder) NullInt16(field int, v sql.NullInt16) {
+	encodeNullField(e, field, v, v.Valid, formatNullInt16)
+}
+
+// NullFloat64 encodes v into the cell bound to field.
+func (e *RowEncoder) NullFloat64(field int, v sql.NullFloat64) {
+	encodeNullField(e, field, v, v.Valid, formatNullFloat64)
+}
+
+// NullBool encodes v into the cell bound to field.
+func (e *RowEncoder) NullBool(field int, v sql.NullBool) {
+	encodeNullField(e, field, v, v.Valid, formatNullBool)
+}
+
+// NullTime encodes v into the cell bound to field.
+func (e *RowEncoder) NullTime(field int, v sql.NullTime) {
+	encodeTimeField(e, field, v, v.Valid, (*timeFormat).formatNull)
+}
+
+// checkGenerated verifies the fields visited by the generated methods of struct type rt against its csv tagged fields.
+// Every field must be visited once with its own type, otherwise the generated code is older than the struct.
+func checkGenerated(rt reflect.Type, fields []structField, probed []probedField) error {
+	seen := make(map[int]bool, len(probed))
+	for _, p := range probed {
+		if p.index < 0 || p.index >= rt.NumField() || seen[p.index] {
+			return ErrGeneratedCodeOutdated
+		}
+		if p.typ != nil && p.typ != rt.Field(p.index).Type {
+			return ErrGeneratedCodeOutdated
+		}
+		seen[p.index] = true
+	}
+	for _, f := range fields {
+		if !seen[f.index] {
+			return ErrGeneratedCodeOutdated
+		}
+	}
+	return nil
+}
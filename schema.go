@@ -0,0 +1,339 @@
+package csvdoc
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	schemaSamples     = 5
+	distinctPrecision = 10
+)
+
+// Schema describes the columns of a csv document as found by InferSchema.
+type Schema struct {
+	Columns []ColumnSchema
+	Rows    int // number of rows scanned, not counting the header and skipped rows
+	Skipped int // number of malformed rows skipped under ErrorPolicySkip or ErrorPolicyCollect
+}
+
+// ColumnSchema describes the values of one csv column. Min and Max are the smallest and largest cells compared as
+// Type, blank cells are not part of Min, Max, Distinct, Layouts and Samples.
+type ColumnSchema struct {
+	Type     reflect.Type // the field type the default converters read every scanned cell into
+	Name     string       // the header of the column, "#N" for documents without a header row
+	Min      string
+	Max      string
+	Layouts  []string // time layouts matching the cells when every cell is a date, including layouts needing a Conversion
+	Samples  []string // the first distinct cells
+	Index    int
+	Nulls    int // number of blank cells, including cells matching a token of WithNullTokens
+	Distinct int // estimated number of distinct cells
+}
+
+// InferSchema reads the csv document from r and reports the type and value statistics of each of its columns. The
+// reader options configure the csv format and null tokens, WithSampleRows limits the number of rows scanned. Malformed
+// rows return an error unless an ErrorPolicy other than ErrorPolicyFailFast is configured.
+//
+// A column is typed int64, uint, float64, bool or time.Time when the default converters read every scanned cell, the
+// matching sql.Null type when blank cells occur and string otherwise. Numbers with leading zeros and cells such as inf
+// or NaN are kept as strings, bool columns only hold true/false, 1/0, yes/no, y/n or on/off.
+func InferSchema(r io.Reader, opts ...Option[ReaderOption]) (*Schema, error) {
+	ro := applyReaderOptions(opts)
+	cr := newCSVReader(r, ro)
+
+	var header []string
+	if ro.readHeader {
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		header = slices.Clone(record)
+	}
+
+	columns := make([]*columnStats, 0, len(header))
+	for i, name := range header {
+		columns = append(columns, newColumnStats(name, i))
+	}
+
+	schema := &Schema{}
+	rowErrors := newRowErrors(ro, nil)
+	for ro.sampleRows <= 0 || schema.Rows < ro.sampleRows {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !rowErrors.skippable(err) {
+				return nil, err
+			}
+			err = rowErrors.handle(record, err)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		schema.Rows++
+		for i, cell := range record {
+			if i == len(columns) {
+				columns = append(columns, newColumnStats("#"+strconv.Itoa(i), i))
+			}
+			if slices.Contains(ro.nullTokens, cell) {
+				cell = ""
+			}
+			columns[i].add(cell)
+		}
+	}
+
+	schema.Skipped = rowErrors.skipped
+	schema.Columns = make([]ColumnSchema, 0, len(columns))
+	for _, c := range columns {
+		schema.Columns = append(schema.Columns, c.schema())
+	}
+	return schema, nil
+}
+
+// commonTimeLayouts returns date layouts often found in csv documents that the default converters do not read.
+func commonTimeLayouts() []string {
+	return []string{
+		"1/2006", "2006-01", "Jan 2006", "January 2006", "1/2/2006", "2006/01/02", "02-Jan-2006", "2-Jan-06", "Jan 2, 2006",
+		"1/2/2006 15:04", "1/2/2006 3:04 PM", "2006-01-02T15:04:05", "2006-01-02 15:04", time.RFC1123, time.RFC1123Z,
+	}
+}
+
+// columnStats accumulates the statistics of a column while InferSchema scans the document.
+type columnStats struct {
+	name     string
+	times    bound[time.Time]
+	strs     bound[string]
+	uints    bound[uint64]
+	floats   bound[float64]
+	ints     bound[int64]
+	matched  []bool
+	samples  []string
+	layouts  []string
+	custom   int
+	values   int
+	index    int
+	nulls    int
+	distinct distinctCounter
+	isInt    bool
+	isUint   bool
+	isFloat  bool
+	isBool   bool
+	isTime   bool
+	isDate   bool
+}
+
+func newColumnStats(name string, index int) *columnStats {
+	layouts := timeLayouts()
+	custom := len(layouts)
+	layouts = append(layouts, commonTimeLayouts()...)
+	return &columnStats{
+		name:    name,
+		index:   index,
+		layouts: layouts,
+		custom:  custom,
+		matched: make([]bool, len(layouts)),
+		isInt:   true,
+		isUint:  true,
+		isFloat: true,
+		isBool:  true,
+		isTime:  true,
+		isDate:  true,
+	}
+}
+
+// add narrows the column types to the ones reading cell and updates the value statistics.
+func (c *columnStats) add(cell string) {
+	if cell == "" {
+		c.nulls++
+		return
+	}
+	c.values++
+	c.distinct.add(cell)
+	c.strs.add(cell, cell, cmp.Compare[string])
+	if len(c.samples) < schemaSamples && !slices.Contains(c.samples, cell) {
+		c.samples = append(c.samples, cell)
+	}
+
+	// numbers with leading zeros are identifiers that would lose the zeros.
+	leadingZero := len(cell) > 1 && cell[0] == '0' && cell[1] >= '0' && cell[1] <= '9'
+	if c.isInt {
+		v, err := parseInt(cell)
+		c.isInt = err == nil && !leadingZero
+		if c.isInt {
+			c.ints.add(v, cell, cmp.Compare[int64])
+		}
+	}
+	if c.isUint {
+		v, err := parseUint(cell)
+		c.isUint = err == nil && !leadingZero
+		if c.isUint {
+			c.uints.add(v, cell, cmp.Compare[uint64])
+		}
+	}
+	if c.isFloat {
+		v, err := parseFloat(cell)
+		c.isFloat = err == nil && !leadingZero && !math.IsInf(v, 0) && !math.IsNaN(v)
+		if c.isFloat {
+			c.floats.add(v, cell, cmp.Compare[float64])
+		}
+	}
+	if c.isBool {
+		switch strings.ToLower(cell) {
+		case "true", "false", "1", "0", "yes", "no", "y", "n", "on", "off":
+		default:
+			c.isBool = false
+		}
+	}
+	if c.isDate {
+		c.addDate(cell)
+	}
+}
+
+// addDate records the first layout parsing cell, layouts from custom on need a Conversion. Once a cell is not a date
+// the column is no longer checked.
+func (c *columnStats) addDate(cell string) {
+	for i, layout := range c.layouts {
+		v, err := time.Parse(layout, cell)
+		if err != nil {
+			continue
+		}
+		c.matched[i] = true
+		c.times.add(v, cell, time.Time.Compare)
+		if i >= c.custom {
+			c.isTime = false
+		}
+		return
+	}
+	c.isDate = false
+	c.isTime = false
+}
+
+// schema returns the ColumnSchema of the scanned cells.
+func (c *columnStats) schema() ColumnSchema {
+	cs := ColumnSchema{
+		Name:     c.name,
+		Index:    c.index,
+		Nulls:    c.nulls,
+		Distinct: c.distinct.estimate(),
+		Samples:  c.samples,
+	}
+	if c.isDate && c.values > 0 {
+		for i, layout := range c.layouts {
+			if c.matched[i] {
+				cs.Layouts = append(cs.Layouts, layout)
+			}
+		}
+	}
+
+	null := c.nulls > 0
+	pick := func(typ any, nullTyp any) reflect.Type {
+		if null {
+			return reflect.TypeOf(nullTyp)
+		}
+		return reflect.TypeOf(typ)
+	}
+	switch {
+	case c.values == 0:
+		cs.Type = reflect.TypeOf("")
+	case c.isInt:
+		cs.Type = pick(int64(0), sql.NullInt64{})
+		cs.Min, cs.Max = c.ints.minCell, c.ints.maxCell
+	case c.isUint:
+		cs.Type = pick(uint(0), sql.Null[uint]{})
+		cs.Min, cs.Max = c.uints.minCell, c.uints.maxCell
+	case c.isFloat:
+		cs.Type = pick(float64(0), sql.NullFloat64{})
+		cs.Min, cs.Max = c.floats.minCell, c.floats.maxCell
+	case c.isBool:
+		cs.Type = pick(false, sql.NullBool{})
+		cs.Min, cs.Max = c.strs.minCell, c.strs.maxCell
+	case c.isTime:
+		cs.Type = pick(time.Time{}, sql.NullTime{})
+		cs.Min, cs.Max = c.times.minCell, c.times.maxCell
+	case c.isDate:
+		cs.Type = reflect.TypeOf("")
+		cs.Min, cs.Max = c.times.minCell, c.times.maxCell
+	default:
+		cs.Type = reflect.TypeOf("")
+		cs.Min, cs.Max = c.strs.minCell, c.strs.maxCell
+	}
+	return cs
+}
+
+// bound keeps the cells holding the smallest and largest value added.
+type bound[V any] struct {
+	min     V
+	max     V
+	minCell string
+	maxCell string
+	set     bool
+}
+
+func (b *bound[V]) add(v V, cell string, compare func(V, V) int) {
+	if !b.set || compare(v, b.min) < 0 {
+		b.min, b.minCell = v, cell
+	}
+	if !b.set || compare(v, b.max) > 0 {
+		b.max, b.maxCell = v, cell
+	}
+	b.set = true
+}
+
+// distinctCounter estimates the number of distinct cells with HyperLogLog, using linear counting for small counts.
+type distinctCounter struct {
+	registers [1 << distinctPrecision]uint8
+}
+
+func (d *distinctCounter) add(cell string) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(cell))
+	x := mix64(h.Sum64())
+	idx := x >> (64 - distinctPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<distinctPrecision|1<<(distinctPrecision-1))) + 1
+	d.registers[idx] = max(d.registers[idx], rank)
+}
+
+func (d *distinctCounter) estimate() int {
+	m := float64(len(d.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range d.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	//nolint:mnd // HyperLogLog bias correction constants.
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	//nolint:mnd // Linear counting is more accurate below 2.5 times the number of registers.
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(e))
+}
+
+// mix64 spreads the bits of the fnv hash, its high bits select the register.
+//
+//nolint:mnd // splitmix64 finalizer constants.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
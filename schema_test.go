@@ -0,0 +1,106 @@
+package csvdoc_test
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+func TestInferSchemaDistinct(t *testing.T) {
+	for _, distinct := range []int{1, 10, 100, 1000, 10000, 50000} {
+		t.Run(fmt.Sprint(distinct), func(t *testing.T) {
+			var b strings.Builder
+			b.WriteString("id,name\n")
+			for i := range 2 * distinct {
+				fmt.Fprintf(&b, "%d,name %d\n", i, i%distinct)
+			}
+			schema, err := csvdoc.InferSchema(strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checks := []struct {
+				name string
+				want int
+			}{
+				{name: "id", want: 2 * distinct},
+				{name: "name", want: distinct},
+			}
+			for i, c := range checks {
+				got := schema.Columns[i].Distinct
+				if diff := math.Abs(float64(got-c.want)) / float64(c.want); diff > 0.05 {
+					t.Errorf("column %s: got %d distinct, want %d within 5%%", c.name, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	doc := "id,big,price,active,at,day,score,name\n" +
+		"-3,18446744073709551615,1.5,yes,2024-01-02 03:04:05,1/2/2024,inf,b\n" +
+		"10,5,-2.25,no,2024-01-01,12/31/2023,NaN,a\n" +
+		",7,3,Y,2024-01-01,1/2/2024,1.5,b\n" +
+		"2,5,0.5,no,,,-Inf,c\n" +
+		"7,5,1e3,n,2023-12-31,1/1/2024,2,d\n" +
+		"0,6,2,on,2024-01-03,1/2/2024,3,e\n" +
+		"1,5,0,off,2024-01-03,1/2/2024,4,f\n"
+	schema, err := csvdoc.InferSchema(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Rows != 7 || schema.Skipped != 0 {
+		t.Errorf("got %d rows and %d skipped, want 7 and 0", schema.Rows, schema.Skipped)
+	}
+
+	want := []csvdoc.ColumnSchema{
+		{
+			Name: "id", Index: 0, Type: reflect.TypeFor[sql.NullInt64](), Nulls: 1, Distinct: 6, Min: "-3", Max: "10",
+			Samples: []string{"-3", "10", "2", "7", "0"},
+		},
+		{
+			Name: "big", Index: 1, Type: reflect.TypeFor[uint](), Distinct: 4, Min: "5", Max: "18446744073709551615",
+			Samples: []string{"18446744073709551615", "5", "7", "6"},
+		},
+		{
+			Name: "price", Index: 2, Type: reflect.TypeFor[float64](), Distinct: 7, Min: "-2.25", Max: "1e3",
+			Samples: []string{"1.5", "-2.25", "3", "0.5", "1e3"},
+		},
+		{
+			Name: "active", Index: 3, Type: reflect.TypeFor[bool](), Distinct: 6, Min: "Y", Max: "yes",
+			Samples: []string{"yes", "no", "Y", "n", "on"},
+		},
+		{
+			Name: "at", Index: 4, Type: reflect.TypeFor[sql.NullTime](), Nulls: 1, Distinct: 4, Min: "2023-12-31",
+			Max: "2024-01-03", Layouts: []string{time.DateTime, time.DateOnly},
+			Samples: []string{"2024-01-02 03:04:05", "2024-01-01", "2023-12-31", "2024-01-03"},
+		},
+		{
+			Name: "day", Index: 5, Type: reflect.TypeFor[string](), Nulls: 1, Distinct: 3, Min: "12/31/2023", Max: "1/2/2024",
+			Layouts: []string{"1/2/2006"}, Samples: []string{"1/2/2024", "12/31/2023", "1/1/2024"},
+		},
+		{
+			Name: "score", Index: 6, Type: reflect.TypeFor[string](), Distinct: 7, Min: "-Inf", Max: "inf",
+			Samples: []string{"inf", "NaN", "1.5", "-Inf", "2"},
+		},
+		{
+			Name: "name", Index: 7, Type: reflect.TypeFor[string](), Distinct: 6, Min: "a", Max: "f",
+			Samples: []string{"b", "a", "c", "d", "e"},
+		},
+	}
+	if len(schema.Columns) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(schema.Columns), len(want))
+	}
+	for i, w := range want {
+		got := schema.Columns[i]
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("column %s: got %+v, want %+v", w.Name, got, w)
+		}
+	}
+}
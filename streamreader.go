@@ -0,0 +1,298 @@
+package csvdoc
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"slices"
+)
+
+// StreamReader is a generic CSV document reader that decodes csv rows from any io.Reader into structs of type T.
+// It shares the header binding and conversion behavior of FileReader and can be used for stdin, HTTP bodies, pipes
+// and in-memory buffers.
+type StreamReader[T any] struct {
+	src         io.Reader
+	closer      io.Closer
+	opts        *ReaderOption
+	headerIndex map[string]int
+	cr          *csv.Reader
+	rowErrors   *rowErrors
+	dec         *RowDecoder
+	fields      []structField
+	columns     []readColumn
+	header      []string
+	start       int64
+	line        int
+	generated   bool
+}
+
+// NewReader creates a new CSV StreamReader reading from r. This reader assumes the csv document has a header
+// and all the header values match the struct tags of type T, unless WithReadHeader(false) is provided. The caller
+// remains responsible for closing r unless WithCloseSource(true) is provided.
+func NewReader[T any](r io.Reader, opts ...Option[ReaderOption]) (*StreamReader[T], error) {
+	plan := planFor[T]()
+	if plan.readErr != nil {
+		return nil, plan.readErr
+	}
+
+	ro := applyReaderOptions(opts)
+	var closer io.Closer
+	if c, ok := r.(io.Closer); ok && ro.closeSource {
+		closer = c
+	}
+
+	return newStreamReader[T](r, closer, plan, ro)
+}
+
+// applyReaderOptions applies opts on top of DefaultReaderOption.
+func applyReaderOptions(opts []Option[ReaderOption]) *ReaderOption {
+	ro := DefaultReaderOption()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(ro)
+		}
+	}
+	return ro
+}
+
+// newCSVReader creates a csv.Reader reading from r configured by opts.
+func newCSVReader(r io.Reader, opts *ReaderOption) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.comma
+	cr.Comment = opts.comment
+	cr.FieldsPerRecord = opts.fieldsPerRecord
+	cr.LazyQuotes = opts.lazyQuotes
+	cr.TrimLeadingSpace = opts.trimLeadingSpace
+	cr.ReuseRecord = opts.reuseRecord
+	return cr
+}
+
+// readHeaderLine reads the header row of the document. Without a header row the positional header of fields is
+// returned and nothing is consumed from cr.
+func readHeaderLine(cr *csv.Reader, fields []structField, opts *ReaderOption) ([]string, error) {
+	if !opts.readHeader {
+		return buildPositionalHeader(fields)
+	}
+	return cr.Read()
+}
+
+// newStreamReader reads the header line from r, or builds a positional one for headerless documents, and binds it to
+// the fields of plan. When closer is not nil the reader owns it and closes it on Close or when Read reaches the end of
+// the document.
+func newStreamReader[T any](r io.Reader, closer io.Closer, plan *typePlan, opts *ReaderOption) (*StreamReader[T], error) {
+	fields := plan.readFields
+	var start int64
+	if rs, ok := r.(io.ReadSeeker); ok {
+		offset, err := rs.Seek(0, io.SeekCurrent)
+		if err == nil {
+			start = offset
+		}
+	}
+
+	cr := newCSVReader(r, opts)
+	headerLine, err := readHeaderLine(cr, fields, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nameIndex, err := buildReadHeaderNameIndexCache(headerLine, fields, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &StreamReader[T]{
+		opts:        opts,
+		src:         r,
+		closer:      closer,
+		start:       start,
+		fields:      fields,
+		columns:     buildReadColumns(fields, nameIndex, opts),
+		generated:   plan.generatedRead,
+		cr:          cr,
+		headerIndex: nameIndex,
+		header:      slices.Clone(headerLine),
+	}
+	sr.dec = newRowDecoder(sr.columns, sr.generated)
+	if opts.readHeader {
+		sr.rowErrors = newRowErrors(opts, sr.header)
+	} else {
+		sr.rowErrors = newRowErrors(opts, nil)
+	}
+	for header, handler := range opts.converters {
+		err = sr.AddConverter(header, handler)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sr, nil
+}
+
+// Close closes the underlying source if it is owned by the reader. Close should be called when done reading, calling
+// it again after the source was closed does nothing.
+func (sr *StreamReader[T]) Close() error {
+	if sr.closer == nil {
+		return nil
+	}
+	closer := sr.closer
+	sr.closer = nil
+	return closer.Close()
+}
+
+// All returns an iterator over the remaining rows for use with range, see All.
+func (sr *StreamReader[T]) All() iter.Seq2[*T, error] {
+	return All[T](sr)
+}
+
+// Reset resets the csv reader back to the row after the header (2nd row). The source must implement io.Seeker,
+// otherwise ErrResetNotSupported is returned.
+func (sr *StreamReader[T]) Reset() error {
+	rs, ok := sr.src.(io.ReadSeeker)
+	if !ok {
+		return ErrResetNotSupported
+	}
+	_, err := rs.Seek(sr.start, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	// csv.Reader buffers its input so a fresh one is required after seeking.
+	sr.cr = newCSVReader(rs, sr.opts)
+	if !sr.opts.readHeader {
+		return nil
+	}
+	_, err = sr.cr.Read()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Read uses csv.Reader to obtain the next line as a []string and then builds a struct of type *T from the []string. Returns EOF and closes an owned source automatically.
+// Rows that fail to convert are skipped when an ErrorPolicy other than ErrorPolicyFailFast is configured.
+func (sr *StreamReader[T]) Read() (*T, error) {
+	t := new(T)
+	err := sr.ReadInto(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// ReadInto is Read that fills the caller owned dst instead of allocating a new T for every row. dst is reset to its
+// zero value before each row so fields not bound to a column never keep values of a previous row.
+func (sr *StreamReader[T]) ReadInto(dst *T) error {
+	return sr.readInto(context.Background(), dst)
+}
+
+// readInto is ReadInto that checks ctx before each record, including records skipped by the ErrorPolicy.
+func (sr *StreamReader[T]) readInto(ctx context.Context, dst *T) error {
+	var zero T
+	for {
+		if ctx.Err() != nil {
+			cerr := sr.Close()
+			if cerr != nil {
+				log.Println("Error closeing file: ", cerr)
+			}
+			return fmt.Errorf("read cancelled after line %d: %w", sr.line, ctx.Err())
+		}
+		line, err := sr.cr.Read()
+		if err != nil && !sr.rowErrors.skippable(err) {
+			cerr := sr.Close()
+			if cerr != nil {
+				log.Println("Error closeing file: ", cerr)
+			}
+			return err
+		}
+		if err == nil {
+			sr.line, _ = sr.cr.FieldPos(0)
+			*dst = zero
+			err = sr.dec.decode(line, dst)
+			if err == nil {
+				return nil
+			}
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				perr.Line, _ = sr.cr.FieldPos(min(perr.Index, len(line)-1))
+			}
+		}
+		err = sr.rowErrors.handle(line, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadContext is Read that stops once ctx is done. The reader is closed and the returned error wraps ctx.Err() with
+// the line the last row read starts on.
+func (sr *StreamReader[T]) ReadContext(ctx context.Context) (*T, error) {
+	t := new(T)
+	err := sr.readInto(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// AddConverter adds a customer Conversion func to handle a specific CSV header/struct tag.
+func (sr *StreamReader[T]) AddConverter(header string, handler Conversion) error {
+	if _, ok := sr.headerIndex[header]; !ok {
+		return ErrNotFoundHeaderInCSV
+	}
+
+	for i := range sr.columns {
+		if sr.columns[i].field.name == header {
+			sr.columns[i].conv = handler
+			sr.columns[i].custom = true
+		}
+	}
+	return nil
+}
+
+// RemoveConverter removes a customer Conversion func for a specific CSV header/struct tag.
+func (sr *StreamReader[T]) RemoveConverter(header string) error {
+	for i := range sr.columns {
+		if sr.columns[i].field.name == header {
+			sr.columns[i].conv = sr.columns[i].field.conv
+			sr.columns[i].custom = false
+		}
+	}
+	return nil
+}
+
+// BoundFields returns the names of the struct fields of T that were bound to a csv column, in struct declaration
+// order. Fields missing from the document are left at their zero value by Read.
+func (sr *StreamReader[T]) BoundFields() []string {
+	bound := make([]string, 0, len(sr.fields))
+	for _, f := range sr.fields {
+		if _, ok := sr.headerIndex[f.name]; ok {
+			bound = append(bound, f.fieldName)
+		}
+	}
+	return bound
+}
+
+// MatchedHeader returns the document header bound to the field with the read name header, which is the first name
+// listed in its csv tag. It reports which alias matched when a field accepts several names.
+func (sr *StreamReader[T]) MatchedHeader(header string) (string, bool) {
+	i, ok := sr.headerIndex[header]
+	if !ok {
+		return "", false
+	}
+	return sr.header[i], true
+}
+
+// Errors returns the errors of the rows skipped under ErrorPolicyCollect.
+func (sr *StreamReader[T]) Errors() []*ParseError {
+	return sr.rowErrors.collected
+}
+
+// SkippedRows returns the number of rows skipped under ErrorPolicySkip or ErrorPolicyCollect.
+func (sr *StreamReader[T]) SkippedRows() int {
+	return sr.rowErrors.skipped
+}
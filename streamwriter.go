@@ -0,0 +1,180 @@
+package csvdoc
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// StreamWriter is a generic CSV document writer that encodes structs of type T as csv rows to any io.Writer.
+// It shares the header handling and conversion behavior of FileWriter and can be used for stdout, network
+// connections, in-memory buffers or compressors.
+type StreamWriter[T any] struct {
+	opts                *WriterOption
+	closer              io.Closer
+	cw                  *csv.Writer
+	enc                 *RowEncoder
+	columns             []writeColumn
+	row                 []string
+	hasWrittenHeaderMux sync.RWMutex
+	hasWrittenHeaders   bool
+	rows                int
+}
+
+// NewWriter creates a new CSV StreamWriter writing to w. If a sort array is not provided, it is assumed the header
+// names will come from the struct csv output tags in struct declaration order, a field with a csvorder:"N" tag is
+// written at the zero based column N. Two fields sharing N return ErrDuplicateColumnOrder, N past the last column
+// ErrColumnOrderOutOfRange. w is only closed by Close when it implements io.Closer and WithCloseDestination(true) is
+// provided.
+func NewWriter[T any](w io.Writer, opts ...Option[WriterOption]) (*StreamWriter[T], error) {
+	writer, err := newStreamWriter[T](w, opts)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := w.(io.Closer); ok && writer.opts.closeDestination {
+		writer.closer = c
+	}
+
+	return writer, nil
+}
+
+// newStreamWriter applies opts and builds the header caches of a StreamWriter writing to w.
+func newStreamWriter[T any](w io.Writer, opts []Option[WriterOption]) (*StreamWriter[T], error) {
+	csvWriter := csv.NewWriter(w)
+
+	writer := &StreamWriter[T]{
+		opts: DefaultWriterOption(),
+		cw:   csvWriter,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(writer.opts)
+		}
+	}
+	csvWriter.Comma = writer.opts.escapeRune
+	csvWriter.UseCRLF = writer.opts.crlfEnable
+
+	plan := planFor[T]()
+	if plan.writeErr != nil {
+		return nil, plan.writeErr
+	}
+	fields := plan.writeFields
+
+	var err error
+	if writer.opts.outputHeader != nil && len(writer.opts.outputHeader) > len(fields) {
+		return nil, ErrToFewStructTags
+	}
+
+	if writer.opts.positional {
+		writer.opts.outputHeader, err = buildPositionalHeader(fields)
+		if err != nil {
+			return nil, err
+		}
+		writer.opts.writeHeader = false
+	}
+
+	if writer.opts.outputHeader == nil {
+		writer.opts.outputHeader, err = buildWriteDefaultHeader(fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nameIndex, err := buildWriteHeaderNameIndexCache(writer.opts.outputHeader, fields, writer.opts.positional)
+	if err != nil {
+		return nil, err
+	}
+	writer.columns = buildWriteColumns(fields, nameIndex, writer.opts)
+	writer.row = make([]string, len(writer.opts.outputHeader))
+	writer.enc = newRowEncoder(writer.columns, writer.row, plan.generatedWrite)
+
+	return writer, nil
+}
+
+// Write converts a Go struct of type T to a []string and writes to the configured io.Writer.
+func (doc *StreamWriter[T]) Write(tm *T) error {
+	var err error
+	doc.hasWrittenHeaderMux.Lock()
+	if !doc.hasWrittenHeaders && doc.opts.writeHeader {
+		err = doc.cw.Write(doc.opts.outputHeader)
+		if err != nil {
+			doc.hasWrittenHeaderMux.Unlock()
+			return err
+		}
+		doc.hasWrittenHeaders = true
+	}
+	doc.hasWrittenHeaderMux.Unlock()
+
+	err = doc.enc.encode(tm)
+	if err != nil {
+		return err
+	}
+
+	err = doc.cw.Write(doc.row)
+	if err != nil {
+		return err
+	}
+	doc.rows++
+	return nil
+}
+
+// WriteContext is Write that stops once ctx is done. The writer is flushed and closed and the returned error wraps
+// ctx.Err() with the number of rows written.
+func (doc *StreamWriter[T]) WriteContext(ctx context.Context, tm *T) error {
+	if ctx.Err() != nil {
+		cerr := doc.Close()
+		if cerr != nil {
+			log.Println("Error closeing file: ", cerr)
+		}
+		return fmt.Errorf("write cancelled after row %d: %w", doc.rows, ctx.Err())
+	}
+	return doc.Write(tm)
+}
+
+// Flush writes any buffered rows to the underlying io.Writer.
+func (doc *StreamWriter[T]) Flush() error {
+	doc.cw.Flush()
+	return doc.cw.Error()
+}
+
+// Close flushes the buffered rows and closes the destination if the writer owns it. Calling Close again after the
+// destination was closed only flushes.
+func (doc *StreamWriter[T]) Close() error {
+	err := doc.Flush()
+	if doc.closer == nil {
+		return err
+	}
+	closer := doc.closer
+	doc.closer = nil
+	cerr := closer.Close()
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
+// AddConverter adds a custom converter function to a specific header/column.
+func (doc *StreamWriter[T]) AddConverter(header string, handler ToStringConversion) error {
+	for i := range doc.columns {
+		if doc.columns[i].field.name == header {
+			doc.columns[i].toString = handler
+			doc.columns[i].custom = true
+		}
+	}
+
+	return nil
+}
+
+// RemoveConverter removes custom converter for specific header.
+func (doc *StreamWriter[T]) RemoveConverter(header string) error {
+	for i := range doc.columns {
+		if doc.columns[i].field.name == header {
+			doc.columns[i].toString = defaultToString(doc.columns[i].field, doc.opts.stringer)
+			doc.columns[i].custom = false
+		}
+	}
+	return nil
+}
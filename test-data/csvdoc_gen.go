@@ -0,0 +1,58 @@
+// Code generated by csvdoc-gen. DO NOT EDIT.
+
+package testdata
+
+import "github.com/tebruno99/csvdoc"
+
+// UnmarshalCSVRow implements csvdoc.CSVRowUnmarshaler.
+func (m *Example) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Time(0, &m.BirthDate)
+	d.NullTime(1, &m.MonYear)
+	d.String(2, &m.SystemID)
+	d.String(3, &m.UserID)
+	d.String(4, &m.Gender)
+	d.String(5, &m.Maximum)
+	d.NullInt64(6, &m.GovID)
+	d.Int64(7, &m.ID)
+	d.Uint(8, &m.Year)
+	d.Float64(9, &m.Minium)
+	d.Bool(10, &m.DoProcess)
+	d.NullBool(11, &m.Validated)
+	return d.Err()
+}
+
+// MarshalCSVRow implements csvdoc.CSVRowMarshaler.
+func (m *Example) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Time(0, m.BirthDate)
+	e.NullTime(1, m.MonYear)
+	e.String(2, m.SystemID)
+	e.String(3, m.UserID)
+	e.String(4, m.Gender)
+	e.String(5, m.Maximum)
+	e.NullInt64(6, m.GovID)
+	e.Int64(7, m.ID)
+	e.Uint(8, m.Year)
+	e.Float64(9, m.Minium)
+	e.Bool(10, m.DoProcess)
+	e.NullBool(11, m.Validated)
+	return e.Err()
+}
+
+// UnmarshalCSVRow implements csvdoc.CSVRowUnmarshaler.
+func (m *ExampleMods) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Time(0, &m.BirthDate)
+	d.String(1, &m.SystemID)
+	d.String(2, &m.UserID)
+	d.String(3, &m.Gender)
+	return d.Err()
+}
+
+// MarshalCSVRow implements csvdoc.CSVRowMarshaler.
+func (m *ExampleMods) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Time(0, m.BirthDate)
+	e.String(1, m.SystemID)
+	e.String(2, m.UserID)
+	e.String(3, m.Gender)
+	e.String(4, m.Errors)
+	return e.Err()
+}
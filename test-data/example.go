@@ -6,10 +6,12 @@ import (
 	"time"
 )
 
+//go:generate go run ../cmd/csvdoc-gen
+
 // Example is a struct that defines each column of the test-data/example.csv.
 type Example struct {
-	BirthDate time.Time     `csv:"birthDate"`
-	MonYear   sql.NullTime  `csv:"MonYear"`
+	BirthDate time.Time     `csv:"birthDate" csvtime:"1/2/2006 3:04:05 PM"`
+	MonYear   sql.NullTime  `csv:"MonYear" csvtime:"1/2006"`
 	SystemID  string        `csv:"systemId"`
 	UserID    string        `csv:"userId"`
 	Gender    string        `csv:"gender"`
@@ -24,7 +26,7 @@ type Example struct {
 
 // ExampleMods is a struct that defines a subset of columns from the test-data/example.csv.
 type ExampleMods struct {
-	BirthDate time.Time `csv:"birthDate,birthDateTime"`
+	BirthDate time.Time `csv:"birthDate,birthDateTime" csvtime:"1/2/2006 3:04:05 PM"`
 	SystemID  string    `csv:"systemId,systemId"`
 	UserID    string    `csv:"userId,userId"`
 	Gender    string    `csv:"gender,gender"`
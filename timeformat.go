@@ -0,0 +1,182 @@
+package csvdoc
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// timeFormat is the layout and location of a time field set with csvtime, csvtimewrite and csvtz tags. layouts are
+// tried in order on read, write is the layout written. Layouts without a zone are read in loc and values are converted
+// to loc before they are written, a nil loc reads UTC and writes values in their own location. Methods on a nil
+// *timeFormat use the default layouts.
+type timeFormat struct {
+	loc     *time.Location
+	write   string
+	layouts []string
+}
+
+// parseTimeTags parses the time tags of a struct field. A csvtime tag lists the "|" separated read layouts, the first
+// one is written unless a csvtimewrite tag names another one. A csvtz tag holds an IANA location name. nil is returned
+// for fields without any of the tags.
+func parseTimeTags(tag reflect.StructTag) (*timeFormat, error) {
+	layoutTag, hasLayout := tag.Lookup("csvtime")
+	writeTag, hasWrite := tag.Lookup("csvtimewrite")
+	zoneTag, hasZone := tag.Lookup("csvtz")
+	if !hasLayout && !hasWrite && !hasZone {
+		return nil, nil
+	}
+
+	tf := &timeFormat{layouts: timeLayouts(), write: time.DateTime}
+	if hasLayout {
+		tf.layouts = strings.Split(layoutTag, "|")
+		if slices.Contains(tf.layouts, "") {
+			return nil, ErrInvalidStructTag
+		}
+		tf.write = tf.layouts[0]
+	}
+	if hasWrite {
+		if writeTag == "" {
+			return nil, ErrInvalidStructTag
+		}
+		tf.write = writeTag
+	}
+	if hasZone {
+		if zoneTag == "" {
+			return nil, ErrInvalidStructTag
+		}
+		loc, err := time.LoadLocation(zoneTag)
+		if err != nil {
+			return nil, ErrInvalidStructTag
+		}
+		tf.loc = loc
+	}
+	return tf, nil
+}
+
+// parse parses the cell of a time.Time field trying each of the layouts.
+func (tf *timeFormat) parse(s string) (time.Time, error) {
+	if tf == nil {
+		return parseTime(s)
+	}
+	if s == "" {
+		return time.Time{}, errors.New("cannot convert empty string to time")
+	}
+	loc := tf.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range tf.layouts {
+		val, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return val, nil
+		}
+	}
+	return time.Time{}, errors.New("cannot convert string to time")
+}
+
+// parseNull parses the cell of a sql.NullTime field, blank cells are not valid.
+func (tf *timeFormat) parseNull(s string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	val, err := tf.parse(s)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: val, Valid: true}, nil
+}
+
+// format formats the value of a time.Time field.
+func (tf *timeFormat) format(t time.Time) string {
+	if tf == nil {
+		return formatTime(t)
+	}
+	if tf.loc != nil {
+		t = t.In(tf.loc)
+	}
+	return t.Format(tf.write)
+}
+
+// formatNull formats the value of a sql.NullTime field, invalid values are written as an empty cell.
+func (tf *timeFormat) formatNull(v sql.NullTime) string {
+	if v.Valid {
+		return tf.format(v.Time)
+	}
+	return ""
+}
+
+// timeConverter returns the Conversion of time.Time and sql.NullTime fields of type t reading with tf, including
+// pointers to them and sql.Null[time.Time]. nil is returned for other types.
+func timeConverter(t reflect.Type, tf *timeFormat) Conversion {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return func(s string, field *reflect.Value) error {
+			val, err := tf.parse(s)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(val))
+			return nil
+		}
+	case reflect.TypeFor[sql.NullTime]():
+		return func(s string, field *reflect.Value) error {
+			val, err := tf.parseNull(s)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(val))
+			return nil
+		}
+	}
+	if t.Kind() == reflect.Pointer {
+		if conv := timeConverter(t.Elem(), tf); conv != nil {
+			return pointerConversion(conv)
+		}
+		return nil
+	}
+	if v, ok := nullValueType(t); ok {
+		if conv := timeConverter(v, tf); conv != nil {
+			return nullConversion(conv)
+		}
+	}
+	return nil
+}
+
+// timeToString returns the ToStringConversion of time.Time and sql.NullTime fields of type t writing with tf,
+// including pointers to them and sql.Null[time.Time]. nil is returned for other types.
+func timeToString(t reflect.Type, tf *timeFormat) ToStringConversion {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return func(v *reflect.Value) (string, error) {
+			tm, ok := v.Interface().(time.Time)
+			if !ok {
+				return "", errors.New("cannot convert to time.Time{}")
+			}
+			return tf.format(tm), nil
+		}
+	case reflect.TypeFor[sql.NullTime]():
+		return func(v *reflect.Value) (string, error) {
+			ns, ok := v.Interface().(sql.NullTime)
+			if !ok {
+				return "", errors.New("cannot convert to sql.NullTime")
+			}
+			return tf.formatNull(ns), nil
+		}
+	}
+	if t.Kind() == reflect.Pointer {
+		if toString := timeToString(t.Elem(), tf); toString != nil {
+			return pointerToString(toString)
+		}
+		return nil
+	}
+	if v, ok := nullValueType(t); ok {
+		if toString := timeToString(v, tf); toString != nil {
+			return nullToString(toString)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package csvdoc_test
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // The csvtz tests should not depend on the zone database of the system.
+
+	"github.com/tebruno99/csvdoc"
+)
+
+type zoneRow struct {
+	At    time.Time    `csv:"at" csvtime:"2006-01-02 15:04" csvtz:"America/New_York"`
+	Plain *time.Time   `csv:"plain" csvtimewrite:"2006-01-02"`
+	Stamp sql.NullTime `csv:"stamp" csvtime:"2006-01-02T15:04:05Z07:00" csvtimewrite:"02.01.2006 15:04" csvtz:"Asia/Tokyo"`
+}
+
+type generatedZoneRow zoneRow
+
+func (m *generatedZoneRow) UnmarshalCSVRow(d *csvdoc.RowDecoder) error {
+	d.Time(0, &m.At)
+	d.Field(1)
+	d.NullTime(2, &m.Stamp)
+	return d.Err()
+}
+
+func (m *generatedZoneRow) MarshalCSVRow(e *csvdoc.RowEncoder) error {
+	e.Time(0, m.At)
+	e.Field(1)
+	e.NullTime(2, m.Stamp)
+	return e.Err()
+}
+
+func TestTimeZoneAndWriteLayout(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "at,plain,stamp\n2024-03-01 10:00,2024-03-01 08:30:00,2024-03-01T12:00:00Z\n2024-07-01 10:00,,\n"
+	plain := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	want := []zoneRow{
+		{
+			At:    time.Date(2024, 3, 1, 10, 0, 0, 0, newYork),
+			Plain: &plain,
+			Stamp: sql.NullTime{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), Valid: true},
+		},
+		{At: time.Date(2024, 7, 1, 10, 0, 0, 0, newYork)},
+	}
+	written := "at,plain,stamp\n2024-03-01 10:00,2024-03-01,01.03.2024 21:00\n2024-07-01 10:00,,\n"
+
+	rows, err := readAll[zoneRow](t, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genRows, err := readAll[generatedZoneRow](t, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		for name, got := range map[string]*zoneRow{"reflection": rows[i], "generated": (*zoneRow)(genRows[i])} {
+			if !got.At.Equal(want[i].At) || got.At.Location().String() != "America/New_York" {
+				t.Errorf("%s row %d: got at %v, want %v", name, i, got.At, want[i].At)
+			}
+			if (got.Plain == nil) != (want[i].Plain == nil) || got.Plain != nil && !got.Plain.Equal(*want[i].Plain) {
+				t.Errorf("%s row %d: got plain %v, want %v", name, i, got.Plain, want[i].Plain)
+			}
+			if got.Stamp.Valid != want[i].Stamp.Valid || !got.Stamp.Time.Equal(want[i].Stamp.Time) {
+				t.Errorf("%s row %d: got stamp %v, want %v", name, i, got.Stamp, want[i].Stamp)
+			}
+		}
+	}
+
+	// values in other locations are converted to the csvtz location before they are written.
+	utcRows := []*zoneRow{
+		{At: time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC), Plain: &plain, Stamp: want[0].Stamp},
+		{At: time.Date(2024, 7, 1, 14, 0, 0, 0, time.UTC)},
+	}
+	out, err := writeRows(t, utcRows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != written {
+		t.Errorf("reflection write: got %q, want %q", out, written)
+	}
+	out, err = writeRows(t, []*generatedZoneRow{(*generatedZoneRow)(utcRows[0]), (*generatedZoneRow)(utcRows[1])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != written {
+		t.Errorf("generated write: got %q, want %q", out, written)
+	}
+}
+
+type badZoneRow struct {
+	At time.Time `csv:"at" csvtz:"Mars/Olympus_Mons"`
+}
+
+type emptyLayoutRow struct {
+	At time.Time `csv:"at" csvtime:"2006-01-02|"`
+}
+
+type timeTagOnStringRow struct {
+	At string `csv:"at" csvtime:"2006-01-02"`
+}
+
+type timeTagOnIntPointerRow struct {
+	At *int `csv:"at" csvtz:"UTC"`
+}
+
+func TestInvalidTimeTags(t *testing.T) {
+	check := func(name string, readErr, writeErr error) {
+		t.Helper()
+		if !errors.Is(readErr, csvdoc.ErrInvalidStructTag) {
+			t.Errorf("%s: NewReader got %v, want %v", name, readErr, csvdoc.ErrInvalidStructTag)
+		}
+		if !errors.Is(writeErr, csvdoc.ErrInvalidStructTag) {
+			t.Errorf("%s: NewWriter got %v, want %v", name, writeErr, csvdoc.ErrInvalidStructTag)
+		}
+	}
+	doc := "at\n2024-01-02\n"
+	var b strings.Builder
+
+	_, readErr := csvdoc.NewReader[badZoneRow](strings.NewReader(doc))
+	_, writeErr := csvdoc.NewWriter[badZoneRow](&b)
+	check("unknown zone", readErr, writeErr)
+
+	_, readErr = csvdoc.NewReader[emptyLayoutRow](strings.NewReader(doc))
+	_, writeErr = csvdoc.NewWriter[emptyLayoutRow](&b)
+	check("empty layout", readErr, writeErr)
+
+	_, readErr = csvdoc.NewReader[timeTagOnStringRow](strings.NewReader(doc))
+	_, writeErr = csvdoc.NewWriter[timeTagOnStringRow](&b)
+	check("string field", readErr, writeErr)
+
+	_, readErr = csvdoc.NewReader[timeTagOnIntPointerRow](strings.NewReader(doc))
+	_, writeErr = csvdoc.NewWriter[timeTagOnIntPointerRow](&b)
+	check("int pointer field", readErr, writeErr)
+}
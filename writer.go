@@ -1,8 +1,12 @@
 package csvdoc
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding"
 	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"time"
@@ -22,27 +26,53 @@ type Writer[T any] interface {
 	RemoveConverter(header string) error                          // Removes a custom conversion function for the specified header.
 }
 
-// buildHeaderNameIndexCache creates two maps representing the csv header and the column number of the header.
-// The first map links column names to their index positions, the second maps indices back to names.
-// It validates that all headers exist in struct tags and checks for duplicate headers.
-func buildWriteHeaderNameIndexCache(headerLine []string, tFieldsIndexes map[string]int) (map[string]int, map[int]string, error) {
+// ContextWriter is a Writer whose writes can be cancelled, implemented by all writers of this package.
+type ContextWriter[T any] interface {
+	Writer[T]
+	WriteContext(ctx context.Context, tm *T) error // Write that closes the writer and stops once ctx is done
+}
+
+// buildWriteHeaderNameIndexCache creates a map linking the struct tag names to their column index in the output header.
+// It validates that all headers exist in struct tags and checks for duplicate headers. With positional set empty
+// headers are unbound column positions left blank.
+func buildWriteHeaderNameIndexCache(headerLine []string, fields []structField, positional bool) (map[string]int, error) {
+	tagNames := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		tagNames[f.name] = struct{}{}
+	}
 	nameIndex := make(map[string]int, len(headerLine))
-	indexName := make(map[int]string, len(headerLine))
 
 	// Collect indexes for headers in struct tags.
 	for i, col := range headerLine {
+		if col == "" && positional {
+			continue
+		}
 		if _, ok := nameIndex[col]; ok {
-			return nil, nil, ErrDuplicateHeaderInCSV
+			return nil, ErrDuplicateHeaderInCSV
 		}
-		if _, ok := tFieldsIndexes[col]; ok {
-			nameIndex[col] = i
-			indexName[i] = col
-		} else {
-			return nil, nil, ErrStructTagNotInCSV
+		if _, ok := tagNames[col]; !ok {
+			return nil, ErrStructTagNotInCSV
 		}
+		nameIndex[col] = i
 	}
 
-	return nameIndex, indexName, nil
+	return nameIndex, nil
+}
+
+// buildWriteDefaultHeader produces the output header used when no header format was provided. Fields with a csvorder
+// tag are written at the column of their order, the other fields follow the struct declaration order.
+func buildWriteDefaultHeader(fields []structField) ([]string, error) {
+	sorted, err := sortFieldsByOrder(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]string, len(sorted))
+	for i, f := range sorted {
+		header[i] = f.name
+	}
+
+	return header, nil
 }
 
 // buildWriteDefaultConverters produces a map[reflect.Type]Conversion for each default handled type. Writer implementations can use
@@ -56,7 +86,7 @@ func buildWriteDefaultConverters() map[reflect.Type]ToStringConversion {
 		return strconv.FormatUint(v.Uint(), 10), nil
 	})
 	floatConversion := ToStringConversion(func(v *reflect.Value) (string, error) {
-		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+		return formatFloat(v.Float()), nil
 	})
 	stringConversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		return v.String(), nil
@@ -69,84 +99,56 @@ func buildWriteDefaultConverters() map[reflect.Type]ToStringConversion {
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullString")
 		}
-		if ns.Valid {
-			return ns.String, nil
-		}
-
-		return "", nil
+		return formatNullString(ns), nil
 	})
 	sqlNullInt64Conversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		ns, ok := v.Interface().(sql.NullInt64)
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullInt64")
 		}
-		if ns.Valid {
-			return strconv.FormatInt(ns.Int64, 10), nil
-		}
-
-		return "", nil
+		return formatNullInt64(ns), nil
 	})
 	sqlNullInt32Conversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		ns, ok := v.Interface().(sql.NullInt32)
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullInt32")
 		}
-		if ns.Valid {
-			return strconv.FormatInt(int64(ns.Int32), 10), nil
-		}
-
-		return "", nil
+		return formatNullInt32(ns), nil
 	})
 	sqlNullInt16Conversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		ns, ok := v.Interface().(sql.NullInt16)
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullInt16")
 		}
-
-		if ns.Valid {
-			return strconv.FormatInt(int64(ns.Int16), 10), nil
-		}
-
-		return "", nil
+		return formatNullInt16(ns), nil
 	})
 	sqlNullTimeConversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		ns, ok := v.Interface().(sql.NullTime)
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullTime")
 		}
-		if ns.Valid {
-			return ns.Time.Format(time.DateTime), nil
-		}
-		return "", nil
+		return formatNullTime(ns), nil
 	})
 	timeConversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		tm, ok := v.Interface().(time.Time)
 		if !ok {
 			return "", errors.New("cannot convert to time.Time{}")
 		}
-		return tm.Format(time.DateTime), nil
+		return formatTime(tm), nil
 	})
 	sqlNullFloat64Conversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		ns, ok := v.Interface().(sql.NullFloat64)
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullFloat64")
 		}
-		if ns.Valid {
-			return strconv.FormatFloat(float64(ns.Float64), 'f', -1, 64), nil
-		}
-
-		return "", nil
+		return formatNullFloat64(ns), nil
 	})
 	sqlNullBoolConversion := ToStringConversion(func(v *reflect.Value) (string, error) {
 		ns, ok := v.Interface().(sql.NullBool)
 		if !ok {
 			return "", errors.New("cannot convert to sql.NullBool")
 		}
-
-		if ns.Valid {
-			return strconv.FormatBool(ns.Bool), nil
-		}
-		return "", nil
+		return formatNullBool(ns), nil
 	})
 	//
 	converts[reflect.TypeOf(int64(1))] = intConversion
@@ -172,3 +174,226 @@ func buildWriteDefaultConverters() map[reflect.Type]ToStringConversion {
 
 	return converts
 }
+
+// writeConverter returns the ToStringConversion of fields of type t, the default converter of t, the converter of the
+// element for pointers and of V for sql.Null[V], otherwise driver.Valuer or encoding.TextMarshaler when t or *t
+// implements them. nil is returned when t has none of them.
+func writeConverter(t reflect.Type) ToStringConversion {
+	if toString, ok := writeDefaultConverters()[t]; ok {
+		return toString
+	}
+	if t.Kind() == reflect.Pointer {
+		if toString := writeConverter(t.Elem()); toString != nil {
+			return pointerToString(toString)
+		}
+		return nil
+	}
+	if v, ok := nullValueType(t); ok {
+		if toString := writeConverter(v); toString != nil {
+			return nullToString(toString)
+		}
+	}
+	valuer := reflect.TypeFor[driver.Valuer]()
+	if t.Implements(valuer) || reflect.PointerTo(t).Implements(valuer) {
+		return formatValuer
+	}
+	marshaler := reflect.TypeFor[encoding.TextMarshaler]()
+	if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+		return marshalText
+	}
+	return nil
+}
+
+// stringerConverter returns formatStringer when t or *t implements fmt.Stringer, otherwise nil. Pointers use the
+// fmt.Stringer of their element.
+func stringerConverter(t reflect.Type) ToStringConversion {
+	if t.Kind() == reflect.Pointer {
+		if toString := stringerConverter(t.Elem()); toString != nil {
+			return pointerToString(toString)
+		}
+		return nil
+	}
+	stringer := reflect.TypeFor[fmt.Stringer]()
+	if t.Implements(stringer) || reflect.PointerTo(t).Implements(stringer) {
+		return formatStringer
+	}
+	return nil
+}
+
+// defaultToString returns the converter a writer uses for field f, its fmt.Stringer is used when the field type has no
+// other converter and stringer is enabled.
+func defaultToString(f *structField, stringer bool) ToStringConversion {
+	if f.toString == nil && stringer {
+		return f.stringer
+	}
+	return f.toString
+}
+
+// isNull reports if v is a nil pointer, points to a null or is a driver.Valuer with a nil value like invalid sql.Null
+// types.
+func isNull(v reflect.Value) bool {
+	if v.Kind() == reflect.Pointer {
+		return v.IsNil() || isNull(v.Elem())
+	}
+	valuer, ok := v.Interface().(driver.Valuer)
+	if !ok && v.CanAddr() {
+		valuer, ok = v.Addr().Interface().(driver.Valuer)
+	}
+	if !ok {
+		return false
+	}
+	value, err := valuer.Value()
+	return err == nil && value == nil
+}
+
+// pointerToString returns the ToStringConversion of a pointer field formatting the pointed to value with toString.
+// nil is written as an empty cell.
+func pointerToString(toString ToStringConversion) ToStringConversion {
+	return func(v *reflect.Value) (string, error) {
+		if v.IsNil() {
+			return "", nil
+		}
+		elem := v.Elem()
+		return toString(&elem)
+	}
+}
+
+// nullToString returns the ToStringConversion of a sql.Null[V] field formatting V with toString. Invalid values are
+// written as an empty cell.
+func nullToString(toString ToStringConversion) ToStringConversion {
+	return func(v *reflect.Value) (string, error) {
+		if !v.Field(1).Bool() {
+			return "", nil
+		}
+		value := v.Field(0)
+		return toString(&value)
+	}
+}
+
+// formatValuer is the ToStringConversion of fields implementing driver.Valuer. A nil value is written as a blank cell,
+// the other driver.Value types like the matching default converters.
+func formatValuer(v *reflect.Value) (string, error) {
+	valuer, ok := v.Interface().(driver.Valuer)
+	if !ok && v.CanAddr() {
+		valuer, ok = v.Addr().Interface().(driver.Valuer)
+	}
+	if !ok {
+		return "", ErrConverterNotFoundForType
+	}
+	value, err := valuer.Value()
+	if err != nil {
+		return "", err
+	}
+
+	switch x := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return x, nil
+	case []byte:
+		return string(x), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return formatFloat(x), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case time.Time:
+		return formatTime(x), nil
+	}
+	return "", fmt.Errorf("unsupported driver.Value type %T", value)
+}
+
+// marshalText is the ToStringConversion of fields implementing encoding.TextMarshaler.
+func marshalText(v *reflect.Value) (string, error) {
+	m, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok && v.CanAddr() {
+		m, ok = v.Addr().Interface().(encoding.TextMarshaler)
+	}
+	if !ok {
+		return "", ErrConverterNotFoundForType
+	}
+	text, err := m.MarshalText()
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// formatStringer is the ToStringConversion of fields implementing fmt.Stringer, enabled by WithStringer.
+func formatStringer(v *reflect.Value) (string, error) {
+	s, ok := v.Interface().(fmt.Stringer)
+	if !ok && v.CanAddr() {
+		s, ok = v.Addr().Interface().(fmt.Stringer)
+	}
+	if !ok {
+		return "", ErrConverterNotFoundForType
+	}
+	return s.String(), nil
+}
+
+// formatFloat formats the value of a float* field. float32 values are widened first.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatTime formats the value of a time.Time field.
+func formatTime(t time.Time) string {
+	return t.Format(time.DateTime)
+}
+
+// formatNullString formats the value of a sql.NullString field, invalid values are written as an empty cell.
+func formatNullString(v sql.NullString) string {
+	if v.Valid {
+		return v.String
+	}
+	return ""
+}
+
+// formatNullInt64 formats the value of a sql.NullInt64 field, invalid values are written as an empty cell.
+func formatNullInt64(v sql.NullInt64) string {
+	if v.Valid {
+		return strconv.FormatInt(v.Int64, 10)
+	}
+	return ""
+}
+
+// formatNullInt32 formats the value of a sql.NullInt32 field, invalid values are written as an empty cell.
+func formatNullInt32(v sql.NullInt32) string {
+	if v.Valid {
+		return strconv.FormatInt(int64(v.Int32), 10)
+	}
+	return ""
+}
+
+// formatNullInt16 formats the value of a sql.NullInt16 field, invalid values are written as an empty cell.
+func formatNullInt16(v sql.NullInt16) string {
+	if v.Valid {
+		return strconv.FormatInt(int64(v.Int16), 10)
+	}
+	return ""
+}
+
+// formatNullFloat64 formats the value of a sql.NullFloat64 field, invalid values are written as an empty cell.
+func formatNullFloat64(v sql.NullFloat64) string {
+	if v.Valid {
+		return formatFloat(v.Float64)
+	}
+	return ""
+}
+
+// formatNullBool formats the value of a sql.NullBool field, invalid values are written as an empty cell.
+func formatNullBool(v sql.NullBool) string {
+	if v.Valid {
+		return strconv.FormatBool(v.Bool)
+	}
+	return ""
+}
+
+// formatNullTime formats the value of a sql.NullTime field, invalid values are written as an empty cell.
+func formatNullTime(v sql.NullTime) string {
+	if v.Valid {
+		return formatTime(v.Time)
+	}
+	return ""
+}
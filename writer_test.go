@@ -0,0 +1,163 @@
+package csvdoc_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tebruno99/csvdoc"
+)
+
+type orderedRow struct {
+	A string `csv:"a"`
+	B string `csv:"b" csvorder:"3"`
+	C string `csv:"c"`
+	D string `csv:"d" csvorder:"0"`
+	E string `csv:"e"`
+}
+
+type duplicateOrderRow struct {
+	A string `csv:"a" csvorder:"1"`
+	B string `csv:"b" csvorder:"1"`
+}
+
+type outOfRangeOrderRow struct {
+	A string `csv:"a"`
+	B string `csv:"b" csvorder:"2"`
+}
+
+func writeRows[T any](t *testing.T, rows []*T, opts ...csvdoc.Option[csvdoc.WriterOption]) ([]byte, error) {
+	t.Helper()
+	var b bytes.Buffer
+	w, err := csvdoc.NewWriter[T](&b, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		err = w.Write(row)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = w.Close()
+	return b.Bytes(), err
+}
+
+func TestWriteColumnOrder(t *testing.T) {
+	got, err := writeRows(t, []*orderedRow{{A: "1", B: "2", C: "3", D: "4", E: "5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d,a,c,b,e\n4,1,3,2,5\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteColumnOrderErrors(t *testing.T) {
+	_, err := writeRows(t, []*duplicateOrderRow{{}})
+	if !errors.Is(err, csvdoc.ErrDuplicateColumnOrder) {
+		t.Errorf("duplicate order: got %v, want %v", err, csvdoc.ErrDuplicateColumnOrder)
+	}
+	_, err = writeRows(t, []*outOfRangeOrderRow{{}})
+	if !errors.Is(err, csvdoc.ErrColumnOrderOutOfRange) {
+		t.Errorf("order out of range: got %v, want %v", err, csvdoc.ErrColumnOrderOutOfRange)
+	}
+}
+
+func TestWriteReproducible(t *testing.T) {
+	rows := []*orderedRow{{A: "1", B: "2", C: "3", D: "4", E: "5"}, {A: "x", B: "y, z", C: "\"q\"", E: "e"}}
+	first, err := writeRows(t, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 20 {
+		again, werr := writeRows(t, rows)
+		if werr != nil {
+			t.Fatal(werr)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("output differs between runs:\n%s\n%s", first, again)
+		}
+	}
+}
+
+func TestWriteEmptyHeaderName(t *testing.T) {
+	_, err := writeRows(t, []*orderedRow{{}}, csvdoc.WithFormatHeaders[csvdoc.WriterOption]([]string{"a", "", "b"}))
+	if !errors.Is(err, csvdoc.ErrStructTagNotInCSV) {
+		t.Errorf("empty header name: got %v, want %v", err, csvdoc.ErrStructTagNotInCSV)
+	}
+
+	got, err := writeRows(t, []*positionalRow{{A: "a", B: 2, C: "c"}},
+		csvdoc.WithPositionalColumns[csvdoc.WriterOption](true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,,2\n"; string(got) != want {
+		t.Errorf("positional: got %q, want %q", got, want)
+	}
+}
+
+func TestWriterCloseOwnership(t *testing.T) {
+	for _, owned := range []bool{false, true} {
+		t.Run(fmt.Sprintf("owned %v", owned), func(t *testing.T) {
+			var b bytes.Buffer
+			dst := &closeTracker{Writer: &b}
+			w, err := csvdoc.NewWriter[orderedRow](dst, csvdoc.WithCloseDestination[csvdoc.WriterOption](owned))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = w.Write(&orderedRow{A: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 2 {
+				err = w.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := 0
+			if owned {
+				want = 1
+			}
+			if dst.closed != want {
+				t.Errorf("got %d closes, want %d", dst.closed, want)
+			}
+			if b.String() != "d,a,c,b,e\n,1,,,\n" {
+				t.Errorf("got %q, want the flushed row", b.String())
+			}
+		})
+	}
+
+	fp := filepath.Join(t.TempDir(), "out.csv")
+	fw, err := csvdoc.NewFileWriter[orderedRow](fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fw.Write(&orderedRow{A: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fw.Write(&orderedRow{A: "2"})
+	if err == nil {
+		err = fw.Flush()
+	}
+	if !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after Close: got %v, want %v", err, os.ErrClosed)
+	}
+	got, err := os.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "d,a,c,b,e\n,1,,,\n" {
+		t.Errorf("got file %q, want the flushed row", got)
+	}
+}